@@ -3,6 +3,7 @@ package graph
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 )
 
@@ -28,6 +29,7 @@ type enode struct {
 
 type GraphL struct {
 	vertex []*vnode
+	codec  Codec //顶点编解码器
 	lock   sync.RWMutex
 }
 
@@ -202,9 +204,62 @@ func (g *GraphL) PrintGraphL() {
 	fmt.Print("\n")
 }
 
+//顶点值及邻接表的快照，供图算法使用
+func (g *GraphL) snapshot() ([]interface{}, [][]int) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	values := make([]interface{}, len(g.vertex))
+	adj := make([][]int, len(g.vertex))
+	for k, v := range g.vertex {
+		values[k] = v.vertex
+		for p := v.next; p != nil; p = p.next {
+			adj[k] = append(adj[k], p.index)
+		}
+	}
+	return values, adj
+}
+
+//顶点值及带权邻接表的快照
+func (g *GraphL) arcs() ([]interface{}, [][]arc) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	values := make([]interface{}, len(g.vertex))
+	adj := make([][]arc, len(g.vertex))
+	for k, v := range g.vertex {
+		values[k] = v.vertex
+		for p := v.next; p != nil; p = p.next {
+			adj[k] = append(adj[k], arc{p.index, p.cost})
+		}
+	}
+	return values, adj
+}
+
+//设置顶点编解码器，为nil时使用DefaultCodec
+func (g *GraphL) SetCodec(c Codec) {
+	g.lock.Lock()
+	g.codec = c
+	g.lock.Unlock()
+}
+
+func (g *GraphL) vertexCodec() Codec {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if g.codec == nil {
+		return DefaultCodec
+	}
+	return g.codec
+}
+
+//用ng的顶点和边替换g的内容
+func (g *GraphL) replace(ng *GraphL) {
+	g.lock.Lock()
+	g.vertex = ng.vertex
+	g.lock.Unlock()
+}
+
 //创建新有向图
 func NewGraphL(v ...interface{}) *GraphL {
-	g := &GraphL{[]*vnode{}}
+	g := &GraphL{vertex: []*vnode{}}
 	for i := range v {
 		g.Insert(v[i])
 	}
@@ -225,8 +280,10 @@ type gnode struct {
 }
 
 type GraphM struct {
-	vertex []*gnode //顶点集合
-	edge   [][]int  //边矩阵
+	vertex []*gnode            //顶点集合
+	edge   matrix              //边矩阵
+	index  map[interface{}]int //顶点值到下标的索引
+	codec  Codec               //顶点编解码器
 	lock   sync.RWMutex
 }
 
@@ -234,14 +291,11 @@ type GraphM struct {
 func (g *GraphM) Insert(v interface{}, nodes ...interface{}) {
 	g.lock.Lock()
 	g.vertex = append(g.vertex, &gnode{v, false})
-	for i := 0; i < len(g.edge); i++ {
-		g.edge[i] = append(g.edge[i], 0)
-	}
-	if len(g.edge) == 0 {
-		g.edge = append(g.edge, make([]int, 1))
-	} else {
-		g.edge = append(g.edge, make([]int, len(g.edge[0])))
+	g.indexVertex(v, len(g.vertex)-1)
+	if g.edge == nil {
+		g.edge = newDense(0)
 	}
+	g.edge.grow()
 	g.lock.Unlock()
 	for _, n := range nodes {
 		g.AddEdge(v, n)
@@ -250,22 +304,14 @@ func (g *GraphM) Insert(v interface{}, nodes ...interface{}) {
 
 //在节点sv和ev之间插入一条边
 func (g *GraphM) AddEdge(sv, ev interface{}) error {
-	si, ei := -1, -1
 	g.lock.Lock()
-	for k, v := range g.vertex {
-		if sv == v.value {
-			si = k
-		}
-		if ev == v.value {
-			ei = k
-		}
-	}
+	si, ei := g.lookup(sv), g.lookup(ev)
 	if si == -1 || ei == -1 {
 		g.lock.Unlock()
 		return errors.New("Make sure the two vertexs are both in graph.")
 	}
-	g.edge[si][ei] = 1
-	g.edge[ei][si] = 1
+	g.edge.set(si, ei, true)
+	g.edge.set(ei, si, true)
 	g.lock.Unlock()
 	return nil
 }
@@ -285,54 +331,46 @@ func (g *GraphM) Delete(d interface{}) error {
 		return errors.New("Make sure the vertex are in graph.")
 	}
 	g.vertex = append(g.vertex[:index], g.vertex[index+1:]...)
-	g.edge = append(g.edge[:index], g.edge[index+1:]...)
-	for i := 0; i < len(g.edge); i++ {
-		g.edge[i] = append(g.edge[i][:index], g.edge[i][index+1:]...)
-	}
+	g.edge.remove(index)
+	g.reindex()
 	g.lock.Unlock()
 	return nil
 }
 
 //删除节点sv和ev之间的边
 func (g *GraphM) DeleteEdge(sv, ev interface{}) error {
-	si, ei := -1, -1
 	g.lock.Lock()
-	for k, v := range g.vertex {
-		if sv == v.value {
-			si = k
-		}
-		if ev == v.value {
-			ei = k
-		}
-	}
+	si, ei := g.lookup(sv), g.lookup(ev)
 	if si == -1 || ei == -1 {
 		g.lock.Unlock()
 		return errors.New("Make sure the two vertexs are both in graph.")
 	}
-	g.edge[si][ei] = 0
-	g.edge[ei][si] = 0
+	g.edge.set(si, ei, false)
+	g.edge.set(ei, si, false)
 	g.lock.Unlock()
 	return nil
 }
 
+//节点sv和ev之间是否有边
+func (g *GraphM) HasEdge(sv, ev interface{}) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	si, ei := g.lookup(sv), g.lookup(ev)
+	if si == -1 || ei == -1 {
+		return false
+	}
+	return g.edge.get(si, ei)
+}
+
 //顶点的度
 func (g *GraphM) Degree(vet interface{}) (degree int) {
-	index := -1
 	g.lock.RLock()
-	for k, v := range g.vertex {
-		if vet == v.value {
-			index = k
-		}
-	}
+	index := g.lookup(vet)
 	if index < 0 {
 		g.lock.Unlock()
 		return -1
 	}
-	for _, v := range g.edge[index] {
-		if v == 1 {
-			degree++
-		}
-	}
+	degree = len(g.edge.neighbors(index))
 	g.lock.RUnlock()
 	return
 }
@@ -365,8 +403,8 @@ func (g *GraphM) bfs(start int) {
 		fmt.Printf("[%d] → ", g.vertex[index].value)
 		g.vertex[index].visit = true
 	outer:
-		for k, v := range g.edge[index] {
-			if v == 1 && !g.vertex[k].visit {
+		for _, k := range g.edge.neighbors(index) {
+			if !g.vertex[k].visit {
 				for _, m := range slice {
 					if k == m {
 						break outer
@@ -399,8 +437,8 @@ func (g *GraphM) DFs(start interface{}) {
 func (g *GraphM) dfs(start int) {
 	fmt.Printf("[%d] → ", g.vertex[start].value)
 	g.vertex[start].visit = true
-	for k, v := range g.edge[start] {
-		if v == 1 && !g.vertex[k].visit {
+	for _, k := range g.edge.neighbors(start) {
+		if !g.vertex[k].visit {
 			g.dfs(k)
 		}
 	}
@@ -417,10 +455,14 @@ func (g *GraphM) PrintGraphM() {
 	}
 	fmt.Print("\n")
 	g.lock.RLock()
-	for k, v := range g.edge {
-		fmt.Printf("%2v|", g.vertex[k].value)
-		for _, m := range v {
-			fmt.Printf("%2d", m)
+	for k, v := range g.vertex {
+		fmt.Printf("%2v|", v.value)
+		for m := range g.vertex {
+			if g.edge.get(k, m) {
+				fmt.Printf("%2d", 1)
+			} else {
+				fmt.Printf("%2d", 0)
+			}
 		}
 		fmt.Print("\n")
 	}
@@ -437,7 +479,97 @@ func (g *GraphM) Reset() {
 	}
 }
 
+//顶点值及邻接表的快照，供图算法使用
+func (g *GraphM) snapshot() ([]interface{}, [][]int) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	values := make([]interface{}, len(g.vertex))
+	adj := make([][]int, len(g.vertex))
+	for k, v := range g.vertex {
+		values[k] = v.value
+		adj[k] = g.edge.neighbors(k)
+	}
+	return values, adj
+}
+
+//设置顶点编解码器，为nil时使用DefaultCodec
+func (g *GraphM) SetCodec(c Codec) {
+	g.lock.Lock()
+	g.codec = c
+	g.lock.Unlock()
+}
+
+func (g *GraphM) vertexCodec() Codec {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if g.codec == nil {
+		return DefaultCodec
+	}
+	return g.codec
+}
+
+//与g存储方式相同的n阶空矩阵
+func (g *GraphM) blank(n int) matrix {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.edge.blank(n)
+}
+
+//邻接矩阵是否以位图存储
+func (g *GraphM) isBitset() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	_, ok := g.edge.(*bitset)
+	return ok
+}
+
+//用ng的顶点和边替换g的内容
+func (g *GraphM) replace(ng *GraphM) {
+	g.lock.Lock()
+	g.vertex, g.edge, g.index = ng.vertex, ng.edge, ng.index
+	g.lock.Unlock()
+}
+
+//重建顶点值到下标的索引
+func (g *GraphM) reindex() {
+	g.index = make(map[interface{}]int, len(g.vertex))
+	for k, v := range g.vertex {
+		g.indexVertex(v.value, k)
+	}
+}
+
+//登记顶点值v的下标，值相同时以后插入的为准，不可比较的值不登记
+func (g *GraphM) indexVertex(v interface{}, k int) {
+	if t := reflect.TypeOf(v); t != nil && !t.Comparable() {
+		return
+	}
+	if g.index == nil {
+		g.index = make(map[interface{}]int)
+	}
+	g.index[v] = k
+}
+
+//顶点值v的下标，不存在时返回-1
+func (g *GraphM) lookup(v interface{}) int {
+	if t := reflect.TypeOf(v); t != nil && !t.Comparable() {
+		return -1
+	}
+	if k, ok := g.index[v]; ok {
+		return k
+	}
+	return -1
+}
+
 //创建新图
 func NewGraphM(v interface{}) *GraphM {
-	return &GraphM{[]*gnode{&gnode{v, false}}, [][]int{[]int{0}}}
+	g := &GraphM{vertex: []*gnode{&gnode{v, false}}, edge: newDense(1)}
+	g.reindex()
+	return g
+}
+
+//创建以位图存储邻接矩阵的新图，每条边只占一个bit
+func NewBitsetGraphM(v interface{}) *GraphM {
+	g := &GraphM{vertex: []*gnode{&gnode{v, false}}, edge: newBitset(1)}
+	g.reindex()
+	return g
 }
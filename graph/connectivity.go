@@ -0,0 +1,126 @@
+package graph
+
+/*
+ * 割点、桥与点双连通分量
+ * Tarjan low-link算法
+ */
+
+type lowlink struct {
+	adj     [][]int
+	dfn     []int //访问次序，0表示未访问
+	low     []int
+	time    int
+	cut     []bool
+	bridges [][2]int
+	stack   [][2]int //边栈
+	comps   [][]int
+}
+
+func newLowlink(adj [][]int) *lowlink {
+	l := &lowlink{
+		adj: adj,
+		dfn: make([]int, len(adj)),
+		low: make([]int, len(adj)),
+		cut: make([]bool, len(adj)),
+	}
+	for k := range adj {
+		if l.dfn[k] == 0 {
+			l.tarjan(k, -1)
+		}
+	}
+	return l
+}
+
+func (l *lowlink) tarjan(v, parent int) {
+	l.time++
+	l.dfn[v], l.low[v] = l.time, l.time
+	children := 0
+	for _, u := range l.adj[v] {
+		if u == v || u == parent {
+			continue
+		}
+		if l.dfn[u] == 0 {
+			children++
+			l.stack = append(l.stack, [2]int{v, u})
+			l.tarjan(u, v)
+			if l.low[u] < l.low[v] {
+				l.low[v] = l.low[u]
+			}
+			if l.low[u] > l.dfn[v] {
+				l.bridges = append(l.bridges, [2]int{v, u})
+			}
+			if l.low[u] >= l.dfn[v] {
+				if parent >= 0 {
+					l.cut[v] = true
+				}
+				l.component(v, u)
+			}
+		} else if l.dfn[u] < l.dfn[v] {
+			l.stack = append(l.stack, [2]int{v, u})
+			if l.dfn[u] < l.low[v] {
+				l.low[v] = l.dfn[u]
+			}
+		}
+	}
+	if parent < 0 && children > 1 {
+		l.cut[v] = true
+	}
+}
+
+//弹出边栈直到边(v,u)，得到一个点双连通分量
+func (l *lowlink) component(v, u int) {
+	seen := map[int]bool{}
+	comp := []int{}
+	for {
+		e := l.stack[len(l.stack)-1]
+		l.stack = l.stack[:len(l.stack)-1]
+		for _, w := range e {
+			if !seen[w] {
+				seen[w] = true
+				comp = append(comp, w)
+			}
+		}
+		if e[0] == v && e[1] == u {
+			break
+		}
+	}
+	l.comps = append(l.comps, comp)
+}
+
+//割点
+func (g *GraphM) ArticulationPoints() []interface{} {
+	values, adj := g.snapshot()
+	l := newLowlink(adj)
+	res := []interface{}{}
+	for k, v := range l.cut {
+		if v {
+			res = append(res, values[k])
+		}
+	}
+	return res
+}
+
+//桥
+func (g *GraphM) Bridges() [][2]interface{} {
+	values, adj := g.snapshot()
+	l := newLowlink(adj)
+	res := make([][2]interface{}, len(l.bridges))
+	for k, e := range l.bridges {
+		res[k] = [2]interface{}{values[e[0]], values[e[1]]}
+	}
+	return res
+}
+
+//点双连通分量，孤立顶点不属于任何分量
+func (g *GraphM) BiconnectedComponents() [][]interface{} {
+	values, adj := g.snapshot()
+	l := newLowlink(adj)
+	res := make([][]interface{}, len(l.comps))
+	for k, comp := range l.comps {
+		res[k] = make([]interface{}, len(comp))
+		for i, v := range comp {
+			res[k][i] = values[v]
+		}
+	}
+	return res
+}
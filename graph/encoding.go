@@ -0,0 +1,389 @@
+package graph
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+)
+
+/*
+ * 图的序列化
+ * JSON: MarshalJSON/UnmarshalJSON，要求顶点编码结果为合法JSON
+ * 二进制: MarshalBinary/UnmarshalBinary，顶点编码结果可以是任意字节
+ * 边按顶点下标保存，权值精确还原
+ */
+
+//顶点编解码器
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+//JSON编解码器
+//New为nil时解码为interface{}（数字会变成float64），
+//否则解码到New返回的指针所指向的类型
+type JSONCodec struct {
+	New func() interface{}
+}
+
+func (c JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c JSONCodec) Decode(data []byte) (interface{}, error) {
+	if c.New == nil {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	p := c.New()
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(p).Elem().Interface(), nil
+}
+
+//保留Go类型的JSON编解码器，编码为["类型名",值]
+//只支持bool、string及各种整数和浮点数（不含命名类型），
+//其他类型的顶点须通过SetCodec指定编解码器，否则编码时返回error
+type TypedCodec struct{}
+
+var basicTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		false, "",
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0), uintptr(0),
+		float32(0), float64(0),
+	} {
+		t := reflect.TypeOf(v)
+		basicTypes[t.String()] = t
+	}
+}
+
+func (c TypedCodec) Encode(v interface{}) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t == nil || basicTypes[t.String()] != t {
+		return nil, errCodec
+	}
+	return json.Marshal([]interface{}{t.String(), v})
+}
+
+func (c TypedCodec) Decode(data []byte) (interface{}, error) {
+	var in []json.RawMessage
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	var name string
+	if len(in) != 2 || json.Unmarshal(in[0], &name) != nil || basicTypes[name] == nil {
+		return nil, errData
+	}
+	p := reflect.New(basicTypes[name])
+	if err := json.Unmarshal(in[1], p.Interface()); err != nil {
+		return nil, err
+	}
+	return p.Elem().Interface(), nil
+}
+
+//未调用SetCodec时使用的编解码器
+var DefaultCodec Codec = TypedCodec{}
+
+var (
+	errData  = errors.New("Invalid graph data.")
+	errCodec = errors.New("Vertex type is not supported by the codec, use SetCodec.")
+)
+
+type graphLJSON struct {
+	Vertices []json.RawMessage `json:"vertices"`
+	Edges    [][3]int          `json:"edges"` //起点下标，终点下标，权值
+}
+
+type graphMJSON struct {
+	Vertices []json.RawMessage `json:"vertices"`
+	Edges    [][2]int          `json:"edges"`
+	Bitset   bool              `json:"bitset,omitempty"`
+}
+
+func encodeVertices(c Codec, values []interface{}) ([][]byte, error) {
+	res := make([][]byte, len(values))
+	for k, v := range values {
+		data, err := c.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		res[k] = data
+	}
+	return res, nil
+}
+
+func decodeVertices(c Codec, data [][]byte) ([]interface{}, error) {
+	values := make([]interface{}, len(data))
+	for k, d := range data {
+		v, err := c.Decode(d)
+		if err != nil {
+			return nil, err
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+func rawVertices(data [][]byte) []json.RawMessage {
+	res := make([]json.RawMessage, len(data))
+	for k := range data {
+		res[k] = data[k]
+	}
+	return res
+}
+
+func bytesVertices(raw []json.RawMessage) [][]byte {
+	res := make([][]byte, len(raw))
+	for k := range raw {
+		res[k] = raw[k]
+	}
+	return res
+}
+
+func (g *GraphL) MarshalJSON() ([]byte, error) {
+	values, adj := g.arcs()
+	data, err := encodeVertices(g.vertexCodec(), values)
+	if err != nil {
+		return nil, err
+	}
+	out := graphLJSON{rawVertices(data), [][3]int{}}
+	for k := range adj {
+		for _, a := range adj[k] {
+			out.Edges = append(out.Edges, [3]int{k, a.index, a.cost})
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (g *GraphL) UnmarshalJSON(data []byte) error {
+	var in graphLJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	values, err := decodeVertices(g.vertexCodec(), bytesVertices(in.Vertices))
+	if err != nil {
+		return err
+	}
+	adj := make([][]arc, len(values))
+	for _, e := range in.Edges {
+		if !inRange(len(values), e[0], e[1]) {
+			return errData
+		}
+		adj[e[0]] = append(adj[e[0]], arc{e[1], e[2]})
+	}
+	g.replace(buildGraphL(values, adj))
+	return nil
+}
+
+func (g *GraphM) MarshalJSON() ([]byte, error) {
+	values, adj := g.snapshot()
+	data, err := encodeVertices(g.vertexCodec(), values)
+	if err != nil {
+		return nil, err
+	}
+	out := graphMJSON{rawVertices(data), [][2]int{}, g.isBitset()}
+	for k := range adj {
+		for _, m := range adj[k] {
+			if m >= k {
+				out.Edges = append(out.Edges, [2]int{k, m})
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (g *GraphM) UnmarshalJSON(data []byte) error {
+	var in graphMJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	values, err := decodeVertices(g.vertexCodec(), bytesVertices(in.Vertices))
+	if err != nil {
+		return err
+	}
+	adj := make([][]int, len(values))
+	for _, e := range in.Edges {
+		if !inRange(len(values), e[0], e[1]) {
+			return errData
+		}
+		adj[e[0]] = append(adj[e[0]], e[1])
+	}
+	g.replace(buildGraphM(newMatrix(in.Bitset, len(values)), values, adj))
+	return nil
+}
+
+//二进制格式：顶点数，各顶点(长度，字节)，边数，各边
+func (g *GraphL) MarshalBinary() ([]byte, error) {
+	values, adj := g.arcs()
+	data, err := encodeVertices(g.vertexCodec(), values)
+	if err != nil {
+		return nil, err
+	}
+	buf := appendVertices(nil, data)
+	edges := 0
+	for k := range adj {
+		edges += len(adj[k])
+	}
+	buf = binary.AppendUvarint(buf, uint64(edges))
+	for k := range adj {
+		for _, a := range adj[k] {
+			buf = binary.AppendUvarint(buf, uint64(k))
+			buf = binary.AppendUvarint(buf, uint64(a.index))
+			buf = binary.AppendVarint(buf, int64(a.cost))
+		}
+	}
+	return buf, nil
+}
+
+func (g *GraphL) UnmarshalBinary(data []byte) error {
+	r := &reader{data: data}
+	raw := r.vertices()
+	edges := r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+	values, err := decodeVertices(g.vertexCodec(), raw)
+	if err != nil {
+		return err
+	}
+	adj := make([][]arc, len(values))
+	for i := 0; i < edges; i++ {
+		s, e, cost := r.uvarint(), r.uvarint(), r.varint()
+		if r.err != nil || !inRange(len(values), s, e) {
+			return errData
+		}
+		adj[s] = append(adj[s], arc{e, cost})
+	}
+	g.replace(buildGraphL(values, adj))
+	return nil
+}
+
+//二进制格式：存储方式，顶点数，各顶点(长度，字节)，边数，各边
+func (g *GraphM) MarshalBinary() ([]byte, error) {
+	values, adj := g.snapshot()
+	data, err := encodeVertices(g.vertexCodec(), values)
+	if err != nil {
+		return nil, err
+	}
+	buf := []byte{0}
+	if g.isBitset() {
+		buf[0] = 1
+	}
+	buf = appendVertices(buf, data)
+	pairs := [][2]int{}
+	for k := range adj {
+		for _, m := range adj[k] {
+			if m >= k {
+				pairs = append(pairs, [2]int{k, m})
+			}
+		}
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(pairs)))
+	for _, p := range pairs {
+		buf = binary.AppendUvarint(buf, uint64(p[0]))
+		buf = binary.AppendUvarint(buf, uint64(p[1]))
+	}
+	return buf, nil
+}
+
+func (g *GraphM) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] > 1 {
+		return errData
+	}
+	r := &reader{data: data[1:]}
+	raw := r.vertices()
+	edges := r.uvarint()
+	if r.err != nil {
+		return r.err
+	}
+	values, err := decodeVertices(g.vertexCodec(), raw)
+	if err != nil {
+		return err
+	}
+	adj := make([][]int, len(values))
+	for i := 0; i < edges; i++ {
+		s, e := r.uvarint(), r.uvarint()
+		if r.err != nil || !inRange(len(values), s, e) {
+			return errData
+		}
+		adj[s] = append(adj[s], e)
+	}
+	g.replace(buildGraphM(newMatrix(data[0] == 1, len(values)), values, adj))
+	return nil
+}
+
+func inRange(n int, indexes ...int) bool {
+	for _, i := range indexes {
+		if i < 0 || i >= n {
+			return false
+		}
+	}
+	return true
+}
+
+func appendVertices(buf []byte, data [][]byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	for _, d := range data {
+		buf = binary.AppendUvarint(buf, uint64(len(d)))
+		buf = append(buf, d...)
+	}
+	return buf
+}
+
+//二进制读取辅助，出错后的读取均返回0
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uvarint() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 || v > math.MaxInt32 {
+		r.err = errData
+		return 0
+	}
+	r.data = r.data[n:]
+	return int(v)
+}
+
+func (r *reader) varint() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errData
+		return 0
+	}
+	r.data = r.data[n:]
+	return int(v)
+}
+
+func (r *reader) vertices() [][]byte {
+	n := r.uvarint()
+	if n > len(r.data) { //每个顶点的长度至少占一个字节
+		r.err = errData
+		return nil
+	}
+	res := make([][]byte, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		size := r.uvarint()
+		if r.err != nil || size > len(r.data) {
+			r.err = errData
+			return nil
+		}
+		res = append(res, r.data[:size])
+		r.data = r.data[size:]
+	}
+	return res
+}
@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"math/bits"
+)
+
+/*
+ * 邻接矩阵存储
+ * dense  每条边占一个int，与原实现相同
+ * bitset 每条边占一个bit，适合上万顶点的图
+ */
+
+type matrix interface {
+	get(i, j int) bool
+	set(i, j int, edge bool)
+	grow()            //增加一个顶点
+	remove(index int) //删除顶点index
+	neighbors(i int) []int
+	blank(n int) matrix //同类型的n阶空矩阵
+}
+
+//n阶空矩阵，bitset为true时以位图存储
+func newMatrix(bitset bool, n int) matrix {
+	if bitset {
+		return newBitset(n)
+	}
+	return newDense(n)
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+稠密矩阵+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+type dense struct {
+	edge [][]int
+}
+
+func newDense(n int) *dense {
+	m := &dense{make([][]int, n)}
+	for i := range m.edge {
+		m.edge[i] = make([]int, n)
+	}
+	return m
+}
+
+func (m *dense) get(i, j int) bool {
+	return m.edge[i][j] == 1
+}
+
+func (m *dense) set(i, j int, edge bool) {
+	if edge {
+		m.edge[i][j] = 1
+	} else {
+		m.edge[i][j] = 0
+	}
+}
+
+func (m *dense) grow() {
+	for i := 0; i < len(m.edge); i++ {
+		m.edge[i] = append(m.edge[i], 0)
+	}
+	m.edge = append(m.edge, make([]int, len(m.edge)+1))
+}
+
+func (m *dense) remove(index int) {
+	m.edge = append(m.edge[:index], m.edge[index+1:]...)
+	for i := 0; i < len(m.edge); i++ {
+		m.edge[i] = append(m.edge[i][:index], m.edge[i][index+1:]...)
+	}
+}
+
+func (m *dense) neighbors(i int) []int {
+	res := []int{}
+	for k, v := range m.edge[i] {
+		if v == 1 {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+func (m *dense) blank(n int) matrix {
+	return newDense(n)
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+位图矩阵+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+type bitset struct {
+	rows [][]uint64
+	n    int
+}
+
+func newBitset(n int) *bitset {
+	m := &bitset{make([][]uint64, n), n}
+	for i := range m.rows {
+		m.rows[i] = make([]uint64, (n+63)/64)
+	}
+	return m
+}
+
+func (m *bitset) get(i, j int) bool {
+	return m.rows[i][j/64]&(1<<uint(j%64)) != 0
+}
+
+func (m *bitset) set(i, j int, edge bool) {
+	if edge {
+		m.rows[i][j/64] |= 1 << uint(j%64)
+	} else {
+		m.rows[i][j/64] &^= 1 << uint(j%64)
+	}
+}
+
+//每64个顶点才为每行增加一个字
+func (m *bitset) grow() {
+	m.n++
+	words := (m.n + 63) / 64
+	if words > (m.n+62)/64 {
+		for i := range m.rows {
+			m.rows[i] = append(m.rows[i], 0)
+		}
+	}
+	m.rows = append(m.rows, make([]uint64, words))
+}
+
+//删除行index，并将每行index之后的位整体右移一位
+func (m *bitset) remove(index int) {
+	m.rows = append(m.rows[:index], m.rows[index+1:]...)
+	m.n--
+	words := (m.n + 63) / 64
+	w, b := index/64, uint(index%64)
+	for i, row := range m.rows {
+		low := row[w] & (1<<b - 1)
+		high := row[w] >> (b + 1) << b
+		row[w] = low | high
+		for k := w; k+1 < len(row); k++ {
+			row[k] |= row[k+1] << 63
+			row[k+1] >>= 1
+		}
+		m.rows[i] = row[:words]
+	}
+}
+
+func (m *bitset) neighbors(i int) []int {
+	res := []int{}
+	for k, word := range m.rows[i] {
+		for word != 0 {
+			res = append(res, k*64+bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return res
+}
+
+func (m *bitset) blank(n int) matrix {
+	return newBitset(n)
+}
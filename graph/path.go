@@ -0,0 +1,251 @@
+package graph
+
+import (
+	"context"
+	"errors"
+)
+
+/*
+ * 欧拉路径与哈密顿路径
+ */
+
+//欧拉路径（Hierholzer算法），不存在时返回error
+func (g *GraphM) EulerianPath() ([]interface{}, error) {
+	values, adj := g.snapshot()
+	start, odd := -1, 0
+	for k := range values {
+		degree := len(adj[k]) + selfloops(adj, k) //自环计两度
+		if degree%2 == 1 {
+			odd++
+			start = k
+		} else if degree > 0 && start < 0 {
+			start = k
+		}
+	}
+	if odd != 0 && odd != 2 {
+		return nil, errors.New("Graph has no eulerian path.")
+	}
+	return undirectedEuler(values, adj, start)
+}
+
+//欧拉回路，不存在时返回error
+func (g *GraphM) EulerianCircuit() ([]interface{}, error) {
+	values, adj := g.snapshot()
+	start := -1
+	for k := range values {
+		degree := len(adj[k]) + selfloops(adj, k)
+		if degree%2 == 1 {
+			return nil, errors.New("Graph has no eulerian circuit.")
+		}
+		if degree > 0 && start < 0 {
+			start = k
+		}
+	}
+	return undirectedEuler(values, adj, start)
+}
+
+//有向图的欧拉路径，不存在时返回error
+func (g *GraphL) EulerianPath() ([]interface{}, error) {
+	values, adj := g.snapshot()
+	indegree := inDegrees(adj)
+	start, out, in := -1, 0, 0
+	for k := range values {
+		diff := len(adj[k]) - indegree[k]
+		switch {
+		case diff == 1:
+			out++
+			start = k
+		case diff == -1:
+			in++
+		case diff != 0:
+			return nil, errors.New("Graph has no eulerian path.")
+		}
+		if out == 0 && start < 0 && len(adj[k]) > 0 {
+			start = k
+		}
+	}
+	if out > 1 || in > 1 || out != in {
+		return nil, errors.New("Graph has no eulerian path.")
+	}
+	return directedEuler(values, adj, start)
+}
+
+//有向图的欧拉回路，不存在时返回error
+func (g *GraphL) EulerianCircuit() ([]interface{}, error) {
+	values, adj := g.snapshot()
+	indegree := inDegrees(adj)
+	start := -1
+	for k := range values {
+		if len(adj[k]) != indegree[k] {
+			return nil, errors.New("Graph has no eulerian circuit.")
+		}
+		if start < 0 && len(adj[k]) > 0 {
+			start = k
+		}
+	}
+	return directedEuler(values, adj, start)
+}
+
+//哈密顿路径（回溯），可通过ctx取消
+func (g *GraphM) HamiltonianPath(ctx context.Context) ([]interface{}, error) {
+	values, adj := g.snapshot()
+	return hamilton(ctx, values, adj)
+}
+
+//有向图的哈密顿路径（回溯），可通过ctx取消
+func (g *GraphL) HamiltonianPath(ctx context.Context) ([]interface{}, error) {
+	values, adj := g.snapshot()
+	return hamilton(ctx, values, adj)
+}
+
+//各顶点的入度，自环计入
+func inDegrees(adj [][]int) []int {
+	in := make([]int, len(adj))
+	for k := range adj {
+		for _, m := range adj[k] {
+			in[m]++
+		}
+	}
+	return in
+}
+
+//顶点k上的自环数
+func selfloops(adj [][]int, k int) (n int) {
+	for _, m := range adj[k] {
+		if m == k {
+			n++
+		}
+	}
+	return
+}
+
+//无向图Hierholzer算法
+func undirectedEuler(values []interface{}, adj [][]int, start int) ([]interface{}, error) {
+	if len(values) == 0 {
+		return nil, errors.New("Graph is empty.")
+	}
+	if start < 0 { //图中无边
+		return []interface{}{values[0]}, nil
+	}
+	edges := 0
+	for k := range adj {
+		for _, m := range adj[k] {
+			if m >= k {
+				edges++
+			}
+		}
+	}
+	used := make(map[[2]int]bool, edges)
+	next := make([]int, len(adj))
+	stack, path := []int{start}, []int{}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		for next[v] < len(adj[v]) && used[edgekey(v, adj[v][next[v]])] {
+			next[v]++
+		}
+		if next[v] == len(adj[v]) {
+			stack = stack[:len(stack)-1]
+			path = append(path, v)
+			continue
+		}
+		u := adj[v][next[v]]
+		used[edgekey(v, u)] = true
+		stack = append(stack, u)
+	}
+	if len(path) != edges+1 { //存在边不连通
+		return nil, errors.New("Graph has no eulerian path.")
+	}
+	return trail(values, path), nil
+}
+
+//有向图Hierholzer算法
+func directedEuler(values []interface{}, adj [][]int, start int) ([]interface{}, error) {
+	if len(values) == 0 {
+		return nil, errors.New("Graph is empty.")
+	}
+	if start < 0 {
+		return []interface{}{values[0]}, nil
+	}
+	edges := 0
+	for k := range adj {
+		edges += len(adj[k])
+	}
+	next := make([]int, len(adj))
+	stack, path := []int{start}, []int{}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		if next[v] == len(adj[v]) {
+			stack = stack[:len(stack)-1]
+			path = append(path, v)
+			continue
+		}
+		stack = append(stack, adj[v][next[v]])
+		next[v]++
+	}
+	if len(path) != edges+1 {
+		return nil, errors.New("Graph has no eulerian path.")
+	}
+	return trail(values, path), nil
+}
+
+//无向边的键
+func edgekey(i, j int) [2]int {
+	if i > j {
+		i, j = j, i
+	}
+	return [2]int{i, j}
+}
+
+//将逆序的下标路径转换为顶点值
+func trail(values []interface{}, path []int) []interface{} {
+	res := make([]interface{}, len(path))
+	for k, v := range path {
+		res[len(path)-1-k] = values[v]
+	}
+	return res
+}
+
+//回溯搜索哈密顿路径
+func hamilton(ctx context.Context, values []interface{}, adj [][]int) ([]interface{}, error) {
+	if len(values) == 0 {
+		return nil, errors.New("Graph is empty.")
+	}
+	visit := make([]bool, len(values))
+	path := make([]int, 0, len(values))
+	var search func(v int) (bool, error)
+	search = func(v int) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		visit[v] = true
+		path = append(path, v)
+		if len(path) == len(values) {
+			return true, nil
+		}
+		for _, u := range adj[v] {
+			if visit[u] {
+				continue
+			}
+			if ok, err := search(u); ok || err != nil {
+				return ok, err
+			}
+		}
+		visit[v] = false
+		path = path[:len(path)-1]
+		return false, nil
+	}
+	for k := range values {
+		ok, err := search(k)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res := make([]interface{}, len(path))
+			for i, v := range path {
+				res[i] = values[v]
+			}
+			return res, nil
+		}
+	}
+	return nil, errors.New("Graph has no hamiltonian path.")
+}
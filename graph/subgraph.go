@@ -0,0 +1,349 @@
+package graph
+
+import (
+	"errors"
+)
+
+/*
+ * 子图、转置、并、交与补图
+ * 均返回新图，不修改原图
+ */
+
+//带权边
+type arc struct {
+	index int
+	cost  int
+}
+
+//由顶点值和带权邻接表构建有向图
+func buildGraphL(values []interface{}, adj [][]arc) *GraphL {
+	g := &GraphL{vertex: make([]*vnode, len(values))}
+	for k, v := range values {
+		g.vertex[k] = &vnode{v, nil}
+		var tail *enode
+		for _, a := range adj[k] {
+			e := &enode{a.index, a.cost, nil}
+			if tail == nil {
+				g.vertex[k].next = e
+			} else {
+				tail.next = e
+			}
+			tail = e
+		}
+	}
+	return g
+}
+
+//由顶点值和带权邻接表构建有向图，编解码器与g相同
+func (g *GraphL) build(values []interface{}, adj [][]arc) *GraphL {
+	ng := buildGraphL(values, adj)
+	ng.codec = g.vertexCodec()
+	return ng
+}
+
+//由顶点值和邻接表构建无向图，矩阵存储方式和编解码器与g相同
+func (g *GraphM) build(values []interface{}, adj [][]int) *GraphM {
+	ng := buildGraphM(g.blank(len(values)), values, adj)
+	ng.codec = g.vertexCodec()
+	return ng
+}
+
+//由顶点值和邻接表构建无向图，edge为len(values)阶空矩阵
+func buildGraphM(edge matrix, values []interface{}, adj [][]int) *GraphM {
+	g := &GraphM{vertex: make([]*gnode, len(values)), edge: edge}
+	for k, v := range values {
+		g.vertex[k] = &gnode{v, false}
+	}
+	g.reindex()
+	for k := range adj {
+		for _, m := range adj[k] {
+			g.edge.set(k, m, true)
+			g.edge.set(m, k, true)
+		}
+	}
+	return g
+}
+
+//顶点值到下标的索引
+func indexOf(values []interface{}) map[interface{}]int {
+	index := make(map[interface{}]int, len(values))
+	for k, v := range values {
+		index[v] = k
+	}
+	return index
+}
+
+//挑选出keep中的顶点，返回新旧下标映射（不保留的顶点为-1）
+func pick(values []interface{}, keep func(int) bool) ([]interface{}, []int) {
+	res := []interface{}{}
+	remap := make([]int, len(values))
+	for k, v := range values {
+		if keep(k) {
+			remap[k] = len(res)
+			res = append(res, v)
+		} else {
+			remap[k] = -1
+		}
+	}
+	return res, remap
+}
+
+//由顶点vertices导出的子图
+func (g *GraphL) InducedSubgraph(vertices ...interface{}) (*GraphL, error) {
+	values, adj := g.arcs()
+	index := indexOf(values)
+	keep := make([]bool, len(values))
+	for _, v := range vertices {
+		k, ok := index[v]
+		if !ok {
+			return nil, errors.New("Make sure the vertex are in graph.")
+		}
+		keep[k] = true
+	}
+	nv, remap := pick(values, func(k int) bool { return keep[k] })
+	nadj := make([][]arc, len(nv))
+	for k := range adj {
+		if remap[k] < 0 {
+			continue
+		}
+		for _, a := range adj[k] {
+			if remap[a.index] >= 0 {
+				nadj[remap[k]] = append(nadj[remap[k]], arc{remap[a.index], a.cost})
+			}
+		}
+	}
+	return g.build(nv, nadj), nil
+}
+
+//由满足pred的边导出的子图，只保留这些边的端点
+func (g *GraphL) EdgeSubgraph(pred func(sv, ev interface{}, cost int) bool) *GraphL {
+	values, adj := g.arcs()
+	keep := make([]bool, len(values))
+	sel := make([][]arc, len(values))
+	for k := range adj {
+		for _, a := range adj[k] {
+			if pred(values[k], values[a.index], a.cost) {
+				sel[k] = append(sel[k], a)
+				keep[k], keep[a.index] = true, true
+			}
+		}
+	}
+	nv, remap := pick(values, func(k int) bool { return keep[k] })
+	nadj := make([][]arc, len(nv))
+	for k := range sel {
+		for _, a := range sel[k] {
+			nadj[remap[k]] = append(nadj[remap[k]], arc{remap[a.index], a.cost})
+		}
+	}
+	return g.build(nv, nadj)
+}
+
+//转置图，所有边反向
+func (g *GraphL) Reverse() *GraphL {
+	values, adj := g.arcs()
+	nadj := make([][]arc, len(values))
+	for k := range adj {
+		for _, a := range adj[k] {
+			nadj[a.index] = append(nadj[a.index], arc{k, a.cost})
+		}
+	}
+	return g.build(values, nadj)
+}
+
+//并图，重复的边保留g中的权值
+func (g *GraphL) Union(g2 *GraphL) *GraphL {
+	values, adj := g.arcs()
+	values2, adj2 := g2.arcs()
+	index := indexOf(values)
+	remap := make([]int, len(values2))
+	for k, v := range values2 {
+		if i, ok := index[v]; ok {
+			remap[k] = i
+		} else {
+			remap[k] = len(values)
+			index[v] = len(values)
+			values = append(values, v)
+			adj = append(adj, nil)
+		}
+	}
+	for k := range adj2 {
+		for _, a := range adj2[k] {
+			s, e := remap[k], remap[a.index]
+			if !hasArc(adj[s], e) {
+				adj[s] = append(adj[s], arc{e, a.cost})
+			}
+		}
+	}
+	return g.build(values, adj)
+}
+
+//交图，边的权值取自g
+func (g *GraphL) Intersection(g2 *GraphL) *GraphL {
+	values, adj := g.arcs()
+	values2, adj2 := g2.arcs()
+	index2 := indexOf(values2)
+	nv, remap := pick(values, func(k int) bool {
+		_, ok := index2[values[k]]
+		return ok
+	})
+	nadj := make([][]arc, len(nv))
+	for k := range adj {
+		if remap[k] < 0 {
+			continue
+		}
+		for _, a := range adj[k] {
+			if remap[a.index] >= 0 && hasArc(adj2[index2[values[k]]], index2[values[a.index]]) {
+				nadj[remap[k]] = append(nadj[remap[k]], arc{remap[a.index], a.cost})
+			}
+		}
+	}
+	return g.build(nv, nadj)
+}
+
+//补图，不含自环，新边权值为0
+func (g *GraphL) Complement() *GraphL {
+	values, adj := g.arcs()
+	nadj := make([][]arc, len(values))
+	for k := range adj {
+		for m := range values {
+			if m != k && !hasArc(adj[k], m) {
+				nadj[k] = append(nadj[k], arc{m, 0})
+			}
+		}
+	}
+	return g.build(values, nadj)
+}
+
+func hasArc(arcs []arc, index int) bool {
+	for _, a := range arcs {
+		if a.index == index {
+			return true
+		}
+	}
+	return false
+}
+
+//由顶点vertices导出的子图
+func (g *GraphM) InducedSubgraph(vertices ...interface{}) (*GraphM, error) {
+	values, adj := g.snapshot()
+	index := indexOf(values)
+	keep := make([]bool, len(values))
+	for _, v := range vertices {
+		k, ok := index[v]
+		if !ok {
+			return nil, errors.New("Make sure the vertex are in graph.")
+		}
+		keep[k] = true
+	}
+	nv, remap := pick(values, func(k int) bool { return keep[k] })
+	nadj := make([][]int, len(nv))
+	for k := range adj {
+		if remap[k] < 0 {
+			continue
+		}
+		for _, m := range adj[k] {
+			if remap[m] >= 0 {
+				nadj[remap[k]] = append(nadj[remap[k]], remap[m])
+			}
+		}
+	}
+	return g.build(nv, nadj), nil
+}
+
+//由满足pred的边导出的子图，只保留这些边的端点
+func (g *GraphM) EdgeSubgraph(pred func(sv, ev interface{}) bool) *GraphM {
+	values, adj := g.snapshot()
+	keep := make([]bool, len(values))
+	sel := make([][]int, len(values))
+	for k := range adj {
+		for _, m := range adj[k] {
+			if m >= k && pred(values[k], values[m]) { //每条无向边只判断一次
+				sel[k] = append(sel[k], m)
+				keep[k], keep[m] = true, true
+			}
+		}
+	}
+	nv, remap := pick(values, func(k int) bool { return keep[k] })
+	nadj := make([][]int, len(nv))
+	for k := range sel {
+		for _, m := range sel[k] {
+			nadj[remap[k]] = append(nadj[remap[k]], remap[m])
+		}
+	}
+	return g.build(nv, nadj)
+}
+
+//无向图的转置即其自身，返回副本
+func (g *GraphM) Reverse() *GraphM {
+	return g.build(g.snapshot())
+}
+
+//并图
+func (g *GraphM) Union(g2 *GraphM) *GraphM {
+	values, adj := g.snapshot()
+	values2, adj2 := g2.snapshot()
+	index := indexOf(values)
+	remap := make([]int, len(values2))
+	for k, v := range values2 {
+		if i, ok := index[v]; ok {
+			remap[k] = i
+		} else {
+			remap[k] = len(values)
+			index[v] = len(values)
+			values = append(values, v)
+			adj = append(adj, nil)
+		}
+	}
+	for k := range adj2 {
+		for _, m := range adj2[k] {
+			adj[remap[k]] = append(adj[remap[k]], remap[m])
+		}
+	}
+	return g.build(values, adj)
+}
+
+//交图
+func (g *GraphM) Intersection(g2 *GraphM) *GraphM {
+	values, adj := g.snapshot()
+	values2, adj2 := g2.snapshot()
+	index2 := indexOf(values2)
+	nv, remap := pick(values, func(k int) bool {
+		_, ok := index2[values[k]]
+		return ok
+	})
+	nadj := make([][]int, len(nv))
+	for k := range adj {
+		if remap[k] < 0 {
+			continue
+		}
+		for _, m := range adj[k] {
+			if remap[m] >= 0 && hasIndex(adj2[index2[values[k]]], index2[values[m]]) {
+				nadj[remap[k]] = append(nadj[remap[k]], remap[m])
+			}
+		}
+	}
+	return g.build(nv, nadj)
+}
+
+//补图，不含自环
+func (g *GraphM) Complement() *GraphM {
+	values, adj := g.snapshot()
+	nadj := make([][]int, len(values))
+	for k := range adj {
+		for m := range values {
+			if m != k && !hasIndex(adj[k], m) {
+				nadj[k] = append(nadj[k], m)
+			}
+		}
+	}
+	return g.build(values, nadj)
+}
+
+func hasIndex(adj []int, index int) bool {
+	for _, m := range adj {
+		if m == index {
+			return true
+		}
+	}
+	return false
+}
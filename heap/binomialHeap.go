@@ -0,0 +1,150 @@
+package heap
+
+import (
+	"errors"
+)
+
+/*
+ * 二项堆（小顶）
+ * 根链表按度数递增排列，Put、Get、Merge均为O(log n)
+ */
+
+type binomial_node struct {
+	data    int
+	degree  int
+	child   *binomial_node //度数最大的孩子
+	sibling *binomial_node
+}
+
+type binomial_heap struct {
+	head *binomial_node
+	size int
+}
+
+//创建二项堆
+func NewBinomialHeap(data ...int) *binomial_heap {
+	h := &binomial_heap{}
+	for i := range data {
+		h.Put(data[i])
+	}
+	return h
+}
+
+//按度数合并两条根链表
+func mergeRoots(a, b *binomial_node) *binomial_node {
+	head := &binomial_node{}
+	tail := head
+	for a != nil && b != nil {
+		if a.degree <= b.degree {
+			tail.sibling, a = a, a.sibling
+		} else {
+			tail.sibling, b = b, b.sibling
+		}
+		tail = tail.sibling
+	}
+	if a != nil {
+		tail.sibling = a
+	} else {
+		tail.sibling = b
+	}
+	return head.sibling
+}
+
+//合并两个二项堆的根链表，使各根度数互不相同
+func union(a, b *binomial_node) *binomial_node {
+	head := mergeRoots(a, b)
+	if head == nil {
+		return nil
+	}
+	var prev *binomial_node
+	x, next := head, head.sibling
+	for next != nil {
+		if x.degree != next.degree || (next.sibling != nil && next.sibling.degree == x.degree) {
+			prev, x = x, next
+		} else if x.data <= next.data {
+			x.sibling = next.sibling
+			next.sibling, x.child = x.child, next
+			x.degree++
+		} else {
+			if prev == nil {
+				head = next
+			} else {
+				prev.sibling = next
+			}
+			x.sibling, next.child = next.child, x
+			next.degree++
+			x = next
+		}
+		next = x.sibling
+	}
+	return head
+}
+
+//添加到堆
+func (h *binomial_heap) Put(data int) {
+	h.head = union(h.head, &binomial_node{data: data})
+	h.size++
+}
+
+//删除堆顶元素并返回
+func (h *binomial_heap) Get() (int, error) {
+	if h.head == nil {
+		return 0, errors.New("Heap is empty.")
+	}
+	var prev, minPrev *binomial_node
+	min := h.head
+	for p := h.head; p != nil; prev, p = p, p.sibling {
+		if p.data < min.data {
+			min, minPrev = p, prev
+		}
+	}
+	if minPrev == nil {
+		h.head = min.sibling
+	} else {
+		minPrev.sibling = min.sibling
+	}
+	//孩子链表按度数递减，反转后再合并
+	var rev *binomial_node
+	for c := min.child; c != nil; {
+		next := c.sibling
+		c.sibling, rev = rev, c
+		c = next
+	}
+	h.head = union(h.head, rev)
+	h.size--
+	return min.data, nil
+}
+
+//获取堆顶元素
+func (h *binomial_heap) Top() (int, error) {
+	if h.head == nil {
+		return 0, errors.New("Heap is empty.")
+	}
+	min := h.head.data
+	for p := h.head.sibling; p != nil; p = p.sibling {
+		if p.data < min {
+			min = p.data
+		}
+	}
+	return min, nil
+}
+
+//将other并入堆，other被清空
+func (h *binomial_heap) Merge(other *binomial_heap) {
+	if h == other {
+		return
+	}
+	h.head = union(h.head, other.head)
+	h.size += other.size
+	other.head, other.size = nil, 0
+}
+
+//堆中元素个数
+func (h *binomial_heap) Len() int {
+	return h.size
+}
+
+//堆是否为空
+func (h *binomial_heap) Empty() bool {
+	return h.size == 0
+}
@@ -0,0 +1,127 @@
+package heap
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrClosed = errors.New("Heap is closed.")
+
+/*
+ * 阻塞优先队列
+ * Take在堆空时阻塞，Offer在堆满时阻塞，均可通过ctx取消
+ * Close后Offer返回ErrClosed，Take取完剩余元素后返回ErrClosed
+ */
+
+type blocking_heap struct {
+	heap    []int
+	less    func(a, b int) bool
+	size    int //容量，0表示无界
+	closed  bool
+	change  chan struct{} //状态变化时关闭并替换，用于唤醒所有等待者
+	waiters int
+	lock    sync.Mutex
+}
+
+func newBlockingHeap(size int, less func(a, b int) bool) *blocking_heap {
+	if size < 0 {
+		size = 0
+	}
+	return &blocking_heap{
+		heap:   []int{},
+		less:   less,
+		size:   size,
+		change: make(chan struct{}),
+	}
+}
+
+//创建阻塞小顶堆，size<=0时无界
+func NewBlockingMinHeap(size int) *blocking_heap {
+	return newBlockingHeap(size, minLess)
+}
+
+//创建阻塞大顶堆，size<=0时无界
+func NewBlockingMaxHeap(size int) *blocking_heap {
+	return newBlockingHeap(size, maxLess)
+}
+
+//唤醒所有等待者，调用时须持有锁
+func (h *blocking_heap) broadcast() {
+	if h.waiters > 0 {
+		close(h.change)
+		h.change = make(chan struct{})
+	}
+}
+
+//释放锁并等待状态变化或ctx取消，返回时重新持有锁
+func (h *blocking_heap) wait(ctx context.Context) error {
+	ch := h.change
+	h.waiters++
+	h.lock.Unlock()
+	var err error
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	h.lock.Lock()
+	h.waiters--
+	return err
+}
+
+//添加到堆，堆满时阻塞
+func (h *blocking_heap) Offer(ctx context.Context, data int) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for !h.closed && h.size > 0 && len(h.heap) >= h.size {
+		if err := h.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if h.closed {
+		return ErrClosed
+	}
+	h.heap = append(h.heap, data)
+	up(h.heap, len(h.heap)-1, h.less)
+	h.broadcast()
+	return nil
+}
+
+//删除堆顶元素并返回，堆空时阻塞
+func (h *blocking_heap) Take(ctx context.Context) (int, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for !h.closed && len(h.heap) == 0 {
+		if err := h.wait(ctx); err != nil {
+			return 0, err
+		}
+	}
+	if len(h.heap) == 0 {
+		return 0, ErrClosed
+	}
+	data := h.heap[0]
+	last := len(h.heap) - 1
+	h.heap[0] = h.heap[last]
+	h.heap = h.heap[:last]
+	down(h.heap, 0, last, h.less)
+	h.broadcast()
+	return data, nil
+}
+
+//堆中元素个数
+func (h *blocking_heap) Len() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.heap)
+}
+
+//关闭堆并唤醒所有等待者
+func (h *blocking_heap) Close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if !h.closed {
+		h.closed = true
+		h.broadcast()
+	}
+}
@@ -0,0 +1,93 @@
+package heap
+
+import (
+	"sync"
+)
+
+/*
+ * 优先通道
+ * 与queue包的通道适配器相同的关闭语义，区别是Out总是输出当前堆顶，
+ * 默认为小顶堆，即优先输出最小值
+ * 元素类型受Interface限制只能是int，pairing、fib等可合并堆同样可用，
+ * DecreaseKey等需要节点句柄的操作无法通过通道进行
+ */
+
+type chan_heap struct {
+	in   chan int
+	out  chan int
+	done chan struct{} //Close时关闭，通知内部goroutine退出
+	exit chan struct{} //内部goroutine退出时关闭
+	heap Interface
+	once sync.Once //关闭in
+	stop sync.Once //关闭done
+}
+
+//创建优先通道，未指定堆时使用小顶堆
+func NewChanHeap(h ...Interface) *chan_heap {
+	c := &chan_heap{
+		in:   make(chan int),
+		out:  make(chan int),
+		done: make(chan struct{}),
+		exit: make(chan struct{}),
+	}
+	if len(h) > 0 && h[0] != nil {
+		c.heap = h[0]
+	} else {
+		c.heap = NewMinHeap()
+	}
+	go c.run()
+	return c
+}
+
+//输入通道
+func (c *chan_heap) In() chan<- int {
+	return c.in
+}
+
+//输出通道，按堆序输出，堆输出空且输入已关闭或Close后关闭
+func (c *chan_heap) Out() <-chan int {
+	return c.out
+}
+
+//停止接收新元素，已入堆的元素仍按堆序从Out输出，可重复调用
+func (c *chan_heap) CloseInput() {
+	c.once.Do(func() {
+		close(c.in)
+	})
+}
+
+//立即停止，丢弃堆中剩余的元素，返回时Out已关闭，可重复调用
+func (c *chan_heap) Close() {
+	c.CloseInput()
+	c.stop.Do(func() {
+		close(c.done)
+	})
+	<-c.exit
+}
+
+func (c *chan_heap) run() {
+	defer close(c.exit)
+	defer close(c.out)
+	in := c.in
+	for {
+		var out chan int
+		top, err := c.heap.Top()
+		if err == nil {
+			out = c.out
+		} else if in == nil {
+			return
+		}
+		select {
+		case data, ok := <-in:
+			if !ok {
+				in = nil
+			} else {
+				c.heap.Put(data)
+			}
+		case out <- top:
+			c.heap.Get()
+		case <-c.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package heap
+
+import (
+	"testing"
+	"time"
+)
+
+//读完Out，返回读到的元素
+func drain(t *testing.T, out <-chan int) []int {
+	t.Helper()
+	res := []int{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case data, ok := <-out:
+			if !ok {
+				return res
+			}
+			res = append(res, data)
+		case <-timeout:
+			t.Fatalf("Out not closed, got %v", res)
+		}
+	}
+}
+
+//CloseInput后堆中剩余元素按从小到大输出，Out随之关闭
+func TestChanHeapCloseInput(t *testing.T) {
+	c := NewChanHeap(NewPairingHeap())
+	for i := 99; i >= 0; i-- {
+		c.In() <- i
+	}
+	c.CloseInput()
+	c.CloseInput()
+	res := drain(t, c.Out())
+	if len(res) != 100 {
+		t.Fatalf("got %d items, want 100", len(res))
+	}
+	for i, data := range res {
+		if data != i {
+			t.Fatalf("item %d = %d", i, data)
+		}
+	}
+	c.Close()
+}
+
+//消费者正在读Out时Close，Out被关闭，消费者不会永远阻塞
+func TestChanHeapCloseWhileReading(t *testing.T) {
+	c := NewChanHeap()
+	for i := 0; i < 10; i++ {
+		c.In() <- i
+	}
+	read := make(chan []int)
+	go func() {
+		res := []int{}
+		for data := range c.Out() {
+			res = append(res, data)
+			if len(res) == 3 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		read <- res
+	}()
+	time.Sleep(5 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return")
+	}
+	select {
+	case res := <-read:
+		if len(res) > 10 {
+			t.Fatalf("got %d items, want at most 10", len(res))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consumer still blocked after Close")
+	}
+	c.Close()
+}
@@ -22,31 +22,30 @@ func NewMinHeap(data ...int) *min_heap {
 	return h
 }
 
-//从切片构建小顶堆
+//从切片构建小顶堆，复制s后自底向上建堆，O(n)
 func MinHeapFromSlice(s []int) *min_heap {
-	h := NewMinHeap()
-	for i := range s {
-		h.Put(s[i])
+	h := &min_heap{
+		heap: make([]int, len(s)),
 	}
+	copy(h.heap, s)
+	heapify(h.heap, minLess)
 	return h
 }
 
+//直接以s为底层存储构建小顶堆，不复制，s中元素的顺序会被调整
+func MinHeapInPlace(s []int) *min_heap {
+	heapify(s, minLess)
+	return &min_heap{
+		heap: s,
+	}
+}
+
 //添加到堆
 func (h *min_heap) Put(data int) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
-	if len(h.heap) == 0 {
-		h.heap = append(h.heap, data)
-	} else {
-		h.heap = append(h.heap, data)
-		child := len(h.heap) - 1
-		parent := (child - 1) / 2
-		for child > 0 && h.heap[child] < h.heap[parent] {
-			h.heap[child], h.heap[parent] = h.heap[parent], h.heap[child]
-			child = parent
-			parent = (child - 1) / 2
-		}
-	}
+	h.heap = append(h.heap, data)
+	up(h.heap, len(h.heap)-1, minLess)
 }
 
 //删除堆顶元素并返回
@@ -61,27 +60,47 @@ func (h *min_heap) Get() (data int, err error) {
 		last := len(h.heap) - 1
 		h.heap[0] = h.heap[last]
 		h.heap = h.heap[0:last]
-		parent := 0
-		for {
-			left, right := 2*parent+1, 2*parent+2
-			if left >= last {
-				break
-			}
-			index := left
-			if right < last && h.heap[right] < h.heap[left] {
-				index = right
-			}
-			if h.heap[parent] > h.heap[index] {
-				h.heap[parent], h.heap[index] = h.heap[index], h.heap[parent]
-			} else {
-				break
-			}
-			parent = index
-		}
+		down(h.heap, 0, last, minLess)
 	}
 	return
 }
 
+//将other中的元素并入堆，other不变
+func (h *min_heap) Merge(other *min_heap) {
+	other.lock.RLock()
+	items := append([]int(nil), other.heap...)
+	other.lock.RUnlock()
+	h.lock.Lock()
+	n := len(h.heap)
+	h.heap = append(h.heap, items...)
+	merge(h.heap, n, minLess)
+	h.lock.Unlock()
+}
+
+//先入堆再取出堆顶，只做一次下沉
+func (h *min_heap) PushPop(data int) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.heap) == 0 || !minLess(h.heap[0], data) {
+		return data
+	}
+	data, h.heap[0] = h.heap[0], data
+	down(h.heap, 0, len(h.heap), minLess)
+	return data
+}
+
+//先取出堆顶再入堆，只做一次下沉，堆空时返回error
+func (h *min_heap) Replace(data int) (int, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	data, h.heap[0] = h.heap[0], data
+	down(h.heap, 0, len(h.heap), minLess)
+	return data, nil
+}
+
 //获取堆顶元素
 func (h *min_heap) Top() (data int, err error) {
 	h.lock.RLock()
@@ -95,6 +114,27 @@ func (h *min_heap) Top() (data int, err error) {
 	return
 }
 
+//堆中元素个数
+func (h *min_heap) Len() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.heap)
+}
+
+//清空堆
+func (h *min_heap) Clear() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.heap = []int{}
+}
+
+//按堆中顺序返回所有元素的副本
+func (h *min_heap) Items() []int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return append([]int(nil), h.heap...)
+}
+
 //堆是否为空
 func (h *min_heap) Empty() bool {
 	h.lock.RLock()
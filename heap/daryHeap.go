@@ -0,0 +1,99 @@
+package heap
+
+import (
+	"errors"
+)
+
+/*
+ * d叉小顶堆
+ * 树更矮，下沉时比较的孩子在内存中连续，适合大堆
+ */
+
+type dary_heap struct {
+	heap []int
+	d    int
+}
+
+//创建d叉小顶堆，d<2时按2处理
+func NewDaryHeap(d int, data ...int) *dary_heap {
+	if d < 2 {
+		d = 2
+	}
+	h := &dary_heap{
+		heap: append([]int{}, data...),
+		d:    d,
+	}
+	for i := (len(h.heap) - 2) / d; i >= 0 && len(h.heap) > 1; i-- {
+		h.down(i)
+	}
+	return h
+}
+
+func (h *dary_heap) up(child int) {
+	for child > 0 {
+		parent := (child - 1) / h.d
+		if h.heap[child] >= h.heap[parent] {
+			break
+		}
+		h.heap[child], h.heap[parent] = h.heap[parent], h.heap[child]
+		child = parent
+	}
+}
+
+func (h *dary_heap) down(parent int) {
+	n := len(h.heap)
+	for {
+		first := h.d*parent + 1
+		if first >= n {
+			break
+		}
+		index := first
+		for c := first + 1; c < first+h.d && c < n; c++ {
+			if h.heap[c] < h.heap[index] {
+				index = c
+			}
+		}
+		if h.heap[index] >= h.heap[parent] {
+			break
+		}
+		h.heap[parent], h.heap[index] = h.heap[index], h.heap[parent]
+		parent = index
+	}
+}
+
+//添加到堆
+func (h *dary_heap) Put(data int) {
+	h.heap = append(h.heap, data)
+	h.up(len(h.heap) - 1)
+}
+
+//删除堆顶元素并返回
+func (h *dary_heap) Get() (int, error) {
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	data := h.heap[0]
+	last := len(h.heap) - 1
+	h.heap[0] = h.heap[last]
+	h.heap = h.heap[:last]
+	h.down(0)
+	return data, nil
+}
+
+//获取堆顶元素
+func (h *dary_heap) Top() (int, error) {
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.heap[0], nil
+}
+
+//堆中元素个数
+func (h *dary_heap) Len() int {
+	return len(h.heap)
+}
+
+//堆是否为空
+func (h *dary_heap) Empty() bool {
+	return len(h.heap) == 0
+}
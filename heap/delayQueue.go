@@ -0,0 +1,185 @@
+package heap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+ * 延迟队列
+ * 按到期时间排序的小顶堆，元素到期后才能被Take取出
+ */
+
+//时钟，测试时可注入假时钟
+//NewTimer返回d之后触发的通道和停止函数，停止函数在计时器尚未触发时返回true
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+//系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	t := time.NewTimer(d)
+	return t.C, t.Stop
+}
+
+//延迟队列中的元素，可用于Cancel
+type delay_item struct {
+	item  interface{}
+	at    time.Time
+	index int //在堆中的下标，-1表示已出队或已取消
+}
+
+type delay_queue struct {
+	heap   []*delay_item
+	clock  Clock
+	change chan struct{} //堆顶可能变化时关闭并替换
+	lock   sync.Mutex
+}
+
+//创建延迟队列，未指定时钟时使用系统时钟
+func NewDelayQueue(clock ...Clock) *delay_queue {
+	q := &delay_queue{
+		heap:   []*delay_item{},
+		clock:  realClock{},
+		change: make(chan struct{}),
+	}
+	if len(clock) > 0 && clock[0] != nil {
+		q.clock = clock[0]
+	}
+	return q
+}
+
+func (q *delay_queue) less(i, j int) bool {
+	return q.heap[i].at.Before(q.heap[j].at)
+}
+
+func (q *delay_queue) swap(i, j int) {
+	q.heap[i], q.heap[j] = q.heap[j], q.heap[i]
+	q.heap[i].index = i
+	q.heap[j].index = j
+}
+
+func (q *delay_queue) up(child int) {
+	for child > 0 {
+		parent := (child - 1) / 2
+		if !q.less(child, parent) {
+			break
+		}
+		q.swap(child, parent)
+		child = parent
+	}
+}
+
+func (q *delay_queue) down(parent int) {
+	n := len(q.heap)
+	for {
+		left, right := 2*parent+1, 2*parent+2
+		if left >= n {
+			break
+		}
+		index := left
+		if right < n && q.less(right, left) {
+			index = right
+		}
+		if !q.less(index, parent) {
+			break
+		}
+		q.swap(parent, index)
+		parent = index
+	}
+}
+
+//删除下标i处的元素
+func (q *delay_queue) remove(i int) *delay_item {
+	last := len(q.heap) - 1
+	if i != last {
+		q.swap(i, last)
+	}
+	d := q.heap[last]
+	q.heap[last] = nil
+	q.heap = q.heap[:last]
+	if i < last {
+		q.down(i)
+		q.up(i)
+	}
+	d.index = -1
+	return d
+}
+
+//唤醒等待者，调用时须持有锁
+func (q *delay_queue) broadcast() {
+	close(q.change)
+	q.change = make(chan struct{})
+}
+
+//添加元素item，在at时刻到期
+func (q *delay_queue) Schedule(item interface{}, at time.Time) *delay_item {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	d := &delay_item{item, at, len(q.heap)}
+	q.heap = append(q.heap, d)
+	q.up(d.index)
+	if d.index == 0 {
+		q.broadcast()
+	}
+	return d
+}
+
+//取消尚未出队的元素，成功时返回true
+func (q *delay_queue) Cancel(d *delay_item) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if d == nil || d.index < 0 || d.index >= len(q.heap) || q.heap[d.index] != d {
+		return false
+	}
+	top := d.index == 0
+	q.remove(d.index)
+	if top {
+		q.broadcast()
+	}
+	return true
+}
+
+//取出最早到期的元素，没有到期元素时阻塞
+//因堆顶变化或ctx结束而醒来时停止本轮的计时器
+func (q *delay_queue) Take(ctx context.Context) (interface{}, error) {
+	for {
+		q.lock.Lock()
+		var timer <-chan time.Time
+		stop := func() bool { return false }
+		if len(q.heap) > 0 {
+			wait := q.heap[0].at.Sub(q.clock.Now())
+			if wait <= 0 {
+				d := q.remove(0)
+				q.lock.Unlock()
+				return d.item, nil
+			}
+			timer, stop = q.clock.NewTimer(wait)
+		}
+		ch := q.change
+		q.lock.Unlock()
+		select {
+		case <-ch:
+			stop()
+		case <-timer:
+		case <-ctx.Done():
+			stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+//队列中元素个数
+func (q *delay_queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.heap)
+}
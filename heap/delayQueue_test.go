@@ -0,0 +1,188 @@
+package heap
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+//假时钟，只有Advance时才前进，记录尚未触发也未停止的计时器
+type fakeClock struct {
+	now    time.Time
+	timers map[*fakeTimer]bool
+	lock   sync.Mutex
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), timers: map[*fakeTimer]bool{}}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTimer{c.now.Add(d), make(chan time.Time, 1)}
+	c.timers[t] = true
+	return t.c, func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		active := c.timers[t]
+		delete(c.timers, t)
+		return active
+	}
+}
+
+//时间前进d，触发所有到期的计时器
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	for t := range c.timers {
+		if !t.at.After(c.now) {
+			t.c <- c.now
+			delete(c.timers, t)
+		}
+	}
+}
+
+//等待直到恰好有n个活动计时器且都在at时刻到期
+func (c *fakeClock) await(t *testing.T, n int, at time.Time) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.lock.Lock()
+		ok := len(c.timers) == n
+		for timer := range c.timers {
+			ok = ok && timer.at.Equal(at)
+		}
+		c.lock.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d timer(s) at %v", n, at)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type taken struct {
+	item interface{}
+	err  error
+}
+
+func take(ctx context.Context, q *delay_queue) chan taken {
+	res := make(chan taken, 1)
+	go func() {
+		item, err := q.Take(ctx)
+		res <- taken{item, err}
+	}()
+	return res
+}
+
+func expect(t *testing.T, res chan taken, item interface{}) {
+	t.Helper()
+	select {
+	case r := <-res:
+		if r.err != nil || r.item != item {
+			t.Fatalf("Take = %v, %v, want %v", r.item, r.err, item)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Take did not return %v", item)
+	}
+}
+
+func TestDelayQueueSchedule(t *testing.T) {
+	c := newFakeClock()
+	q := NewDelayQueue(c)
+	start := c.Now()
+	q.Schedule("b", start.Add(2*time.Second))
+	q.Schedule("a", start.Add(time.Second))
+	res := take(context.Background(), q)
+	c.await(t, 1, start.Add(time.Second))
+	select {
+	case r := <-res:
+		t.Fatalf("Take returned %v before its deadline", r.item)
+	default:
+	}
+	c.Advance(time.Second)
+	expect(t, res, "a")
+	c.Advance(time.Second)
+	expect(t, take(context.Background(), q), "b")
+	c.await(t, 0, time.Time{})
+}
+
+//取消堆顶后Take改等下一个元素，旧计时器被停止
+func TestDelayQueueCancel(t *testing.T) {
+	c := newFakeClock()
+	q := NewDelayQueue(c)
+	start := c.Now()
+	a := q.Schedule("a", start.Add(time.Second))
+	q.Schedule("b", start.Add(3*time.Second))
+	res := take(context.Background(), q)
+	c.await(t, 1, start.Add(time.Second))
+	if !q.Cancel(a) {
+		t.Fatal("Cancel of queued item failed")
+	}
+	if q.Cancel(a) {
+		t.Fatal("Cancel of cancelled item succeeded")
+	}
+	c.await(t, 1, start.Add(3*time.Second))
+	c.Advance(3 * time.Second)
+	expect(t, res, "b")
+	if q.Len() != 0 {
+		t.Fatalf("Len = %d, want 0", q.Len())
+	}
+}
+
+//更早到期的新元素抢占堆顶，Take换用新的计时器
+func TestDelayQueuePreempt(t *testing.T) {
+	c := newFakeClock()
+	q := NewDelayQueue(c)
+	start := c.Now()
+	q.Schedule("late", start.Add(10*time.Second))
+	res := take(context.Background(), q)
+	c.await(t, 1, start.Add(10*time.Second))
+	q.Schedule("early", start.Add(2*time.Second))
+	c.await(t, 1, start.Add(2*time.Second))
+	c.Advance(2 * time.Second)
+	expect(t, res, "early")
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", q.Len())
+	}
+}
+
+//ctx结束时Take返回ctx.Err()并停止计时器
+func TestDelayQueueContext(t *testing.T) {
+	c := newFakeClock()
+	q := NewDelayQueue(c)
+	start := c.Now()
+	q.Schedule("a", start.Add(time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	res := take(ctx, q)
+	c.await(t, 1, start.Add(time.Second))
+	cancel()
+	select {
+	case r := <-res:
+		if r.err != context.Canceled {
+			t.Fatalf("Take = %v, %v, want context.Canceled", r.item, r.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Take did not return after cancel")
+	}
+	c.await(t, 0, time.Time{})
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", q.Len())
+	}
+}
@@ -0,0 +1,246 @@
+package heap
+
+import (
+	"errors"
+)
+
+/*
+ * 斐波那契堆（小顶）
+ * Put、Merge、DecreaseKey均摊O(1)，Get均摊O(log n)
+ */
+
+type fib_node struct {
+	data    int
+	degree  int
+	mark    bool
+	parent  *fib_node
+	child   *fib_node
+	left    *fib_node //兄弟节点构成双向循环链表
+	right   *fib_node
+	owner   *owner
+	removed bool //已被Get取出
+}
+
+type fib_heap struct {
+	min  *fib_node
+	size int
+	id   *owner
+}
+
+//创建斐波那契堆
+func NewFibHeap(data ...int) *fib_heap {
+	h := &fib_heap{}
+	for i := range data {
+		h.Put(data[i])
+	}
+	return h
+}
+
+//从所在链表中摘除
+func (n *fib_node) unlink() {
+	n.left.right = n.right
+	n.right.left = n.left
+	n.left, n.right = n, n
+}
+
+//将单个节点n插入到a之后
+func (a *fib_node) splice(n *fib_node) {
+	n.left, n.right = a, a.right
+	a.right.left = n
+	a.right = n
+}
+
+//加入根链表
+func (h *fib_heap) addRoot(n *fib_node) {
+	n.parent = nil
+	if h.min == nil {
+		n.left, n.right = n, n
+		h.min = n
+		return
+	}
+	h.min.splice(n)
+	if n.data < h.min.data {
+		h.min = n
+	}
+}
+
+func (h *fib_heap) owner() *owner {
+	if h.id == nil {
+		h.id = &owner{}
+	}
+	return h.id
+}
+
+//添加到堆
+func (h *fib_heap) Put(data int) {
+	h.Insert(data)
+}
+
+//添加到堆，返回可用于DecreaseKey的节点
+func (h *fib_heap) Insert(data int) *fib_node {
+	n := &fib_node{data: data, owner: h.owner()}
+	h.addRoot(n)
+	h.size++
+	return n
+}
+
+//删除堆顶元素并返回
+func (h *fib_heap) Get() (int, error) {
+	z := h.min
+	if z == nil {
+		return 0, errors.New("Heap is empty.")
+	}
+	for z.child != nil {
+		c := z.child
+		if c.right == c {
+			z.child = nil
+		} else {
+			z.child = c.right
+			c.unlink()
+		}
+		h.min.splice(c)
+		c.parent = nil
+	}
+	if z.right == z {
+		h.min = nil
+	} else {
+		h.min = z.right
+		z.unlink()
+		h.consolidate()
+	}
+	z.removed = true
+	h.size--
+	return z.data, nil
+}
+
+//合并度数相同的根，直到所有根的度数不同
+func (h *fib_heap) consolidate() {
+	roots := []*fib_node{h.min}
+	for p := h.min.right; p != h.min; p = p.right {
+		roots = append(roots, p)
+	}
+	degree := []*fib_node{}
+	for _, x := range roots {
+		d := x.degree
+		for d < len(degree) && degree[d] != nil {
+			y := degree[d]
+			if y.data < x.data {
+				x, y = y, x
+			}
+			h.link(y, x)
+			degree[d] = nil
+			d++
+		}
+		for d >= len(degree) {
+			degree = append(degree, nil)
+		}
+		degree[d] = x
+	}
+	h.min = nil
+	for _, x := range degree {
+		if x != nil && (h.min == nil || x.data < h.min.data) {
+			h.min = x
+		}
+	}
+}
+
+//将根y变为根x的孩子
+func (h *fib_heap) link(y, x *fib_node) {
+	if h.min == y {
+		h.min = x
+	}
+	y.unlink()
+	y.parent = x
+	if x.child == nil {
+		x.child = y
+	} else {
+		x.child.splice(y)
+	}
+	x.degree++
+	y.mark = false
+}
+
+//获取堆顶元素
+func (h *fib_heap) Top() (int, error) {
+	if h.min == nil {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.min.data, nil
+}
+
+//将节点n的值减小为data，n已被取出或不属于该堆时返回error
+func (h *fib_heap) DecreaseKey(n *fib_node, data int) error {
+	if n == nil || n.removed || h.id == nil || n.owner.find() != h.id {
+		return errNode
+	}
+	if data > n.data {
+		return errors.New("New key is greater than current key.")
+	}
+	n.data = data
+	if p := n.parent; p != nil && n.data < p.data {
+		h.cut(n, p)
+		h.cascadingCut(p)
+	}
+	if n.data < h.min.data {
+		h.min = n
+	}
+	return nil
+}
+
+//将x从父节点y中剪下，加入根链表
+func (h *fib_heap) cut(x, y *fib_node) {
+	if x.right == x {
+		y.child = nil
+	} else {
+		if y.child == x {
+			y.child = x.right
+		}
+		x.unlink()
+	}
+	y.degree--
+	x.mark = false
+	h.addRoot(x)
+}
+
+func (h *fib_heap) cascadingCut(y *fib_node) {
+	for z := y.parent; z != nil; y, z = z, z.parent {
+		if !y.mark {
+			y.mark = true
+			return
+		}
+		h.cut(y, z)
+	}
+}
+
+//将other并入堆，other被清空
+func (h *fib_heap) Merge(other *fib_heap) {
+	if h == other || other.min == nil {
+		return
+	}
+	if h.min == nil {
+		h.min = other.min
+	} else {
+		a, b := h.min, other.min
+		ar, bl := a.right, b.left
+		a.right, b.left = b, a
+		bl.right, ar.left = ar, bl
+		if b.data < a.data {
+			h.min = b
+		}
+	}
+	h.size += other.size
+	if other.id != nil {
+		other.id.next = h.owner()
+	}
+	other.min, other.size, other.id = nil, 0, nil
+}
+
+//堆中元素个数
+func (h *fib_heap) Len() int {
+	return h.size
+}
+
+//堆是否为空
+func (h *fib_heap) Empty() bool {
+	return h.size == 0
+}
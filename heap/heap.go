@@ -0,0 +1,41 @@
+package heap
+
+import (
+	"errors"
+)
+
+//堆的公共接口，小顶堆、大顶堆及可合并堆均实现了该接口
+type Interface interface {
+	Put(data int)
+	Get() (int, error)
+	Top() (int, error)
+	Empty() bool
+}
+
+var (
+	_ Interface = (*min_heap)(nil)
+	_ Interface = (*max_heap)(nil)
+	_ Interface = (*pairing_heap)(nil)
+	_ Interface = (*fib_heap)(nil)
+	_ Interface = (*binomial_heap)(nil)
+	_ Interface = (*dary_heap)(nil)
+)
+
+//可合并堆的归属标记，用于检查DecreaseKey的节点是否属于该堆
+//Merge后被并入的堆的标记指向合并后的堆（并查集），节点无需逐个更新
+type owner struct {
+	next *owner
+}
+
+//当前所属堆的标记
+func (o *owner) find() *owner {
+	for o.next != nil {
+		if o.next.next != nil {
+			o.next = o.next.next
+		}
+		o = o.next
+	}
+	return o
+}
+
+var errNode = errors.New("Node is not in heap.")
@@ -0,0 +1,64 @@
+package heap
+
+import (
+	"errors"
+)
+
+/*
+ * 流式中位数
+ * 大顶堆保存较小的一半，小顶堆保存较大的一半
+ * 大顶堆的元素个数等于小顶堆或比其多一个
+ */
+
+type median struct {
+	low   *max_heap
+	high  *min_heap
+	nlow  int
+	nhigh int
+}
+
+//创建中位数统计
+func NewMedian() *median {
+	return &median{
+		low:  NewMaxHeap(),
+		high: NewMinHeap(),
+	}
+}
+
+//加入元素，O(log n)
+func (m *median) Put(data int) {
+	if top, err := m.low.Top(); err != nil || data <= top {
+		m.low.Put(data)
+		m.nlow++
+	} else {
+		m.high.Put(data)
+		m.nhigh++
+	}
+	if m.nlow > m.nhigh+1 {
+		data, _ := m.low.Get()
+		m.high.Put(data)
+		m.nlow, m.nhigh = m.nlow-1, m.nhigh+1
+	} else if m.nhigh > m.nlow {
+		data, _ := m.high.Get()
+		m.low.Put(data)
+		m.nlow, m.nhigh = m.nlow+1, m.nhigh-1
+	}
+}
+
+//当前中位数，元素个数为偶数时取中间两数的平均值
+func (m *median) Median() (float64, error) {
+	if m.nlow == 0 {
+		return 0, errors.New("No data.")
+	}
+	low, _ := m.low.Top()
+	if m.nlow > m.nhigh {
+		return float64(low), nil
+	}
+	high, _ := m.high.Top()
+	return (float64(low) + float64(high)) / 2, nil
+}
+
+//已加入的元素个数
+func (m *median) Len() int {
+	return m.nlow + m.nhigh
+}
@@ -0,0 +1,179 @@
+package heap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+//可合并堆的参照模型，记录未取出的节点及其值和已取出的节点
+type reference struct {
+	r    *rand.Rand
+	seq  int
+	live map[interface{}]int
+	dead []interface{}
+}
+
+func newReference() *reference {
+	return &reference{r: rand.New(rand.NewSource(1)), live: map[interface{}]int{}}
+}
+
+//新节点的值，低位为序号，保证各节点的值互不相同
+func (ref *reference) key() int {
+	ref.seq++
+	return ref.r.Intn(1000)<<20 | ref.seq
+}
+
+//检查Get的结果是否为参照中的最小值，并把对应节点移入已取出
+func (ref *reference) get(t *testing.T, step, data int, err error) {
+	min, node := 0, interface{}(nil)
+	for n, v := range ref.live {
+		if node == nil || v < min {
+			min, node = v, n
+		}
+	}
+	if node == nil {
+		if err == nil {
+			t.Fatalf("step %d: Get on empty heap returned %d", step, data)
+		}
+		return
+	}
+	if err != nil || data != min {
+		t.Fatalf("step %d: Get = %d, %v, want %d", step, data, err, min)
+	}
+	delete(ref.live, node)
+	ref.dead = append(ref.dead, node)
+}
+
+//随机挑选一个未取出的节点并给出减小后的值
+func (ref *reference) decrease() (interface{}, int, bool) {
+	for n, v := range ref.live {
+		v -= ref.r.Intn(100) << 20
+		ref.live[n] = v
+		return n, v, true
+	}
+	return nil, 0, false
+}
+
+//随机挑选一个已取出的节点
+func (ref *reference) removed() (interface{}, bool) {
+	if len(ref.dead) == 0 {
+		return nil, false
+	}
+	return ref.dead[ref.r.Intn(len(ref.dead))], true
+}
+
+//随机执行Insert、Get、DecreaseKey、Merge，与参照模型比较
+//同时检查已取出的节点和已并入其他堆的句柄不能再用于DecreaseKey
+func TestPairingHeapFuzz(t *testing.T) {
+	ref := newReference()
+	h := NewPairingHeap()
+	for step := 0; step < 5000; step++ {
+		switch op := ref.r.Intn(10); {
+		case op < 4:
+			data := ref.key()
+			ref.live[h.Insert(data)] = data
+		case op < 7:
+			data, err := h.Get()
+			ref.get(t, step, data, err)
+		case op < 9:
+			if n, v, ok := ref.decrease(); ok {
+				if err := h.DecreaseKey(n.(*pairing_node), v); err != nil {
+					t.Fatalf("step %d: DecreaseKey: %v", step, err)
+				}
+			}
+			if n, ok := ref.removed(); ok {
+				if err := h.DecreaseKey(n.(*pairing_node), -1<<50); err == nil {
+					t.Fatalf("step %d: DecreaseKey on removed node succeeded", step)
+				}
+			}
+		default:
+			other := NewPairingHeap()
+			nodes := []*pairing_node{}
+			for i := ref.r.Intn(5); i > 0; i-- {
+				data := ref.key()
+				n := other.Insert(data)
+				ref.live[n] = data
+				nodes = append(nodes, n)
+			}
+			h.Merge(other)
+			for _, n := range nodes {
+				if err := other.DecreaseKey(n, -1<<50); err == nil {
+					t.Fatalf("step %d: DecreaseKey through merged heap succeeded", step)
+				}
+			}
+		}
+		if h.Len() != len(ref.live) {
+			t.Fatalf("step %d: Len = %d, want %d", step, h.Len(), len(ref.live))
+		}
+	}
+}
+
+func TestFibHeapFuzz(t *testing.T) {
+	ref := newReference()
+	h := NewFibHeap()
+	for step := 0; step < 5000; step++ {
+		switch op := ref.r.Intn(10); {
+		case op < 4:
+			data := ref.key()
+			ref.live[h.Insert(data)] = data
+		case op < 7:
+			data, err := h.Get()
+			ref.get(t, step, data, err)
+		case op < 9:
+			if n, v, ok := ref.decrease(); ok {
+				if err := h.DecreaseKey(n.(*fib_node), v); err != nil {
+					t.Fatalf("step %d: DecreaseKey: %v", step, err)
+				}
+			}
+			if n, ok := ref.removed(); ok {
+				if err := h.DecreaseKey(n.(*fib_node), -1<<50); err == nil {
+					t.Fatalf("step %d: DecreaseKey on removed node succeeded", step)
+				}
+			}
+		default:
+			other := NewFibHeap()
+			nodes := []*fib_node{}
+			for i := ref.r.Intn(5); i > 0; i-- {
+				data := ref.key()
+				n := other.Insert(data)
+				ref.live[n] = data
+				nodes = append(nodes, n)
+			}
+			h.Merge(other)
+			for _, n := range nodes {
+				if err := other.DecreaseKey(n, -1<<50); err == nil {
+					t.Fatalf("step %d: DecreaseKey through merged heap succeeded", step)
+				}
+			}
+		}
+		if h.Len() != len(ref.live) {
+			t.Fatalf("step %d: Len = %d, want %d", step, h.Len(), len(ref.live))
+		}
+	}
+}
+
+//取出后的节点不能再DecreaseKey
+func TestDecreaseKeyRemoved(t *testing.T) {
+	p := NewPairingHeap()
+	n5 := p.Insert(5)
+	p.Insert(7)
+	p.Insert(9)
+	p.Get()
+	if err := p.DecreaseKey(n5, 1); err == nil {
+		t.Fatal("pairing heap: DecreaseKey on removed node succeeded")
+	}
+	if data, _ := p.Get(); data != 7 {
+		t.Fatalf("pairing heap: Get = %d, want 7", data)
+	}
+
+	f := NewFibHeap()
+	m5 := f.Insert(5)
+	f.Insert(7)
+	f.Get()
+	if err := f.DecreaseKey(m5, 1); err == nil {
+		t.Fatal("fib heap: DecreaseKey on removed node succeeded")
+	}
+	if data, _ := f.Get(); data != 7 {
+		t.Fatalf("fib heap: Get = %d, want 7", data)
+	}
+}
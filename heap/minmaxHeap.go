@@ -0,0 +1,166 @@
+package heap
+
+import (
+	"errors"
+	"math/bits"
+)
+
+/*
+ * 最小-最大堆（双端优先队列）
+ * 偶数层为最小层，奇数层为最大层
+ * 根为最小值，最大值在根的两个孩子之一
+ */
+
+type minmax_heap struct {
+	heap []int
+}
+
+//创建最小-最大堆
+func NewMinMaxHeap(data ...int) *minmax_heap {
+	h := &minmax_heap{
+		heap: []int{},
+	}
+	for i := range data {
+		h.Put(data[i])
+	}
+	return h
+}
+
+//下标i是否位于最小层
+func minLevel(i int) bool {
+	return bits.Len(uint(i+1))%2 == 1
+}
+
+//添加到堆
+func (h *minmax_heap) Put(data int) {
+	h.heap = append(h.heap, data)
+	i := len(h.heap) - 1
+	if i == 0 {
+		return
+	}
+	parent := (i - 1) / 2
+	if minLevel(i) {
+		if h.heap[i] > h.heap[parent] {
+			h.heap[i], h.heap[parent] = h.heap[parent], h.heap[i]
+			h.up(parent, maxLess)
+		} else {
+			h.up(i, minLess)
+		}
+	} else {
+		if h.heap[i] < h.heap[parent] {
+			h.heap[i], h.heap[parent] = h.heap[parent], h.heap[i]
+			h.up(parent, minLess)
+		} else {
+			h.up(i, maxLess)
+		}
+	}
+}
+
+//沿祖父节点上浮
+func (h *minmax_heap) up(i int, less func(a, b int) bool) {
+	for i > 2 {
+		gp := (i - 3) / 4
+		if !less(h.heap[i], h.heap[gp]) {
+			break
+		}
+		h.heap[i], h.heap[gp] = h.heap[gp], h.heap[i]
+		i = gp
+	}
+}
+
+//下沉，最小层用minLess，最大层用maxLess
+func (h *minmax_heap) down(i int) {
+	less := maxLess
+	if minLevel(i) {
+		less = minLess
+	}
+	n := len(h.heap)
+	for 2*i+1 < n {
+		//在孩子和孙子中找出最优的
+		m := 2*i + 1
+		for _, c := range [...]int{2*i + 2, 4*i + 3, 4*i + 4, 4*i + 5, 4*i + 6} {
+			if c < n && less(h.heap[c], h.heap[m]) {
+				m = c
+			}
+		}
+		if !less(h.heap[m], h.heap[i]) {
+			break
+		}
+		h.heap[i], h.heap[m] = h.heap[m], h.heap[i]
+		if m <= 2*i+2 { //孩子
+			break
+		}
+		if parent := (m - 1) / 2; less(h.heap[parent], h.heap[m]) {
+			h.heap[m], h.heap[parent] = h.heap[parent], h.heap[m]
+		}
+		i = m
+	}
+}
+
+//删除下标i处的元素并返回
+func (h *minmax_heap) remove(i int) int {
+	data := h.heap[i]
+	last := len(h.heap) - 1
+	h.heap[i] = h.heap[last]
+	h.heap = h.heap[:last]
+	if i < last {
+		h.down(i)
+	}
+	return data
+}
+
+//最大值的下标
+func (h *minmax_heap) maxIndex() int {
+	switch len(h.heap) {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	}
+	if h.heap[2] > h.heap[1] {
+		return 2
+	}
+	return 1
+}
+
+//删除最小值并返回
+func (h *minmax_heap) PopMin() (int, error) {
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.remove(0), nil
+}
+
+//删除最大值并返回
+func (h *minmax_heap) PopMax() (int, error) {
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.remove(h.maxIndex()), nil
+}
+
+//获取最小值
+func (h *minmax_heap) Min() (int, error) {
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.heap[0], nil
+}
+
+//获取最大值
+func (h *minmax_heap) Max() (int, error) {
+	if len(h.heap) == 0 {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.heap[h.maxIndex()], nil
+}
+
+//堆中元素个数
+func (h *minmax_heap) Len() int {
+	return len(h.heap)
+}
+
+//堆是否为空
+func (h *minmax_heap) Empty() bool {
+	return len(h.heap) == 0
+}
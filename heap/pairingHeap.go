@@ -0,0 +1,163 @@
+package heap
+
+import (
+	"errors"
+)
+
+/*
+ * 配对堆（小顶）
+ * Put、Merge、DecreaseKey均摊O(1)，Get均摊O(log n)
+ */
+
+type pairing_node struct {
+	data    int
+	child   *pairing_node
+	sibling *pairing_node
+	prev    *pairing_node //最左孩子指向父节点，其余指向左兄弟
+	owner   *owner
+	removed bool //已被Get取出
+}
+
+type pairing_heap struct {
+	root *pairing_node
+	size int
+	id   *owner
+}
+
+//创建配对堆
+func NewPairingHeap(data ...int) *pairing_heap {
+	h := &pairing_heap{}
+	for i := range data {
+		h.Put(data[i])
+	}
+	return h
+}
+
+//合并两棵树，返回新根
+func (a *pairing_node) meld(b *pairing_node) *pairing_node {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if b.data < a.data {
+		a, b = b, a
+	}
+	b.prev = a
+	b.sibling = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	return a
+}
+
+//两趟合并兄弟链表
+func (n *pairing_node) mergePairs() *pairing_node {
+	pairs := []*pairing_node{}
+	for n != nil {
+		a, b := n, n.sibling
+		if b == nil {
+			n = nil
+		} else {
+			n = b.sibling
+			b.sibling, b.prev = nil, nil
+		}
+		a.sibling, a.prev = nil, nil
+		pairs = append(pairs, a.meld(b))
+	}
+	var root *pairing_node
+	for i := len(pairs) - 1; i >= 0; i-- {
+		root = pairs[i].meld(root)
+	}
+	return root
+}
+
+func (h *pairing_heap) owner() *owner {
+	if h.id == nil {
+		h.id = &owner{}
+	}
+	return h.id
+}
+
+//添加到堆
+func (h *pairing_heap) Put(data int) {
+	h.Insert(data)
+}
+
+//添加到堆，返回可用于DecreaseKey的节点
+func (h *pairing_heap) Insert(data int) *pairing_node {
+	n := &pairing_node{data: data, owner: h.owner()}
+	h.root = h.root.meld(n)
+	h.size++
+	return n
+}
+
+//删除堆顶元素并返回
+func (h *pairing_heap) Get() (int, error) {
+	if h.root == nil {
+		return 0, errors.New("Heap is empty.")
+	}
+	top := h.root
+	h.root = top.child.mergePairs()
+	top.child, top.removed = nil, true
+	h.size--
+	return top.data, nil
+}
+
+//获取堆顶元素
+func (h *pairing_heap) Top() (int, error) {
+	if h.root == nil {
+		return 0, errors.New("Heap is empty.")
+	}
+	return h.root.data, nil
+}
+
+//将节点n的值减小为data，n已被取出或不属于该堆时返回error
+func (h *pairing_heap) DecreaseKey(n *pairing_node, data int) error {
+	if n == nil || n.removed || h.id == nil || n.owner.find() != h.id {
+		return errNode
+	}
+	if data > n.data {
+		return errors.New("New key is greater than current key.")
+	}
+	n.data = data
+	if n == h.root {
+		return nil
+	}
+	if n.prev.child == n {
+		n.prev.child = n.sibling
+	} else {
+		n.prev.sibling = n.sibling
+	}
+	if n.sibling != nil {
+		n.sibling.prev = n.prev
+	}
+	n.sibling, n.prev = nil, nil
+	h.root = h.root.meld(n)
+	return nil
+}
+
+//将other并入堆，other被清空
+func (h *pairing_heap) Merge(other *pairing_heap) {
+	if h == other {
+		return
+	}
+	h.root = h.root.meld(other.root)
+	h.size += other.size
+	if other.id != nil {
+		other.id.next = h.owner()
+	}
+	other.root, other.size, other.id = nil, 0, nil
+}
+
+//堆中元素个数
+func (h *pairing_heap) Len() int {
+	return h.size
+}
+
+//堆是否为空
+func (h *pairing_heap) Empty() bool {
+	return h.size == 0
+}
@@ -0,0 +1,62 @@
+package heap
+
+/*
+ * 堆调整的公共例程
+ * less(a, b)为true表示a应位于b之上
+ */
+
+func minLess(a, b int) bool {
+	return a < b
+}
+
+func maxLess(a, b int) bool {
+	return a > b
+}
+
+//上浮下标为child的元素
+func up[T any](h []T, child int, less func(a, b T) bool) {
+	parent := (child - 1) / 2
+	for child > 0 && less(h[child], h[parent]) {
+		h[child], h[parent] = h[parent], h[child]
+		child = parent
+		parent = (child - 1) / 2
+	}
+}
+
+//在h[:n]中下沉下标为parent的元素
+func down[T any](h []T, parent, n int, less func(a, b T) bool) {
+	for {
+		left, right := 2*parent+1, 2*parent+2
+		if left >= n {
+			break
+		}
+		index := left
+		if right < n && less(h[right], h[left]) {
+			index = right
+		}
+		if !less(h[index], h[parent]) {
+			break
+		}
+		h[parent], h[index] = h[index], h[parent]
+		parent = index
+	}
+}
+
+//自底向上建堆(Floyd)，O(n)
+func heapify[T any](h []T, less func(a, b T) bool) {
+	for i := len(h)/2 - 1; i >= 0; i-- {
+		down(h, i, len(h), less)
+	}
+}
+
+//将h[n:]中新追加的元素并入堆h[:n]
+//追加较多时整体重建，否则逐个上浮
+func merge[T any](h []T, n int, less func(a, b T) bool) {
+	if 2*(len(h)-n) >= n {
+		heapify(h, less)
+		return
+	}
+	for i := n; i < len(h); i++ {
+		up(h, i, less)
+	}
+}
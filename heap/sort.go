@@ -0,0 +1,68 @@
+package heap
+
+/*
+ * 堆排序与部分排序
+ * 直接在用户切片上原地进行，不创建堆对象
+ * less(a, b)为true表示a应排在b之前
+ */
+
+//反转比较函数，用于建立以“最后”元素为顶的堆
+func reverse(less func(a, b int) bool) func(a, b int) bool {
+	return func(a, b int) bool {
+		return less(b, a)
+	}
+}
+
+//堆排序，O(n log n)，不稳定
+func Sort(s []int, less func(a, b int) bool) {
+	greater := reverse(less)
+	heapify(s, greater)
+	for end := len(s) - 1; end > 0; end-- {
+		s[0], s[end] = s[end], s[0]
+		down(s, 0, end, greater)
+	}
+}
+
+//部分排序，完成后s[:k]为按less排列的前k个元素，其余元素顺序不定，O(n log k)
+func PartialSort(s []int, k int, less func(a, b int) bool) {
+	if k > len(s) {
+		k = len(s)
+	}
+	if k <= 0 {
+		return
+	}
+	greater := reverse(less)
+	heapify(s[:k], greater)
+	for i := k; i < len(s); i++ {
+		if less(s[i], s[0]) {
+			s[0], s[i] = s[i], s[0]
+			down(s, 0, k, greater)
+		}
+	}
+	for end := k - 1; end > 0; end-- {
+		s[0], s[end] = s[end], s[0]
+		down(s, 0, end, greater)
+	}
+}
+
+//最小的n个元素，从小到大排列，返回的切片与s共享底层数组
+func NSmallest(s []int, n int) []int {
+	PartialSort(s, n, minLess)
+	return s[:clamp(n, len(s))]
+}
+
+//最大的n个元素，从大到小排列，返回的切片与s共享底层数组
+func NLargest(s []int, n int) []int {
+	PartialSort(s, n, maxLess)
+	return s[:clamp(n, len(s))]
+}
+
+func clamp(n, limit int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > limit {
+		return limit
+	}
+	return n
+}
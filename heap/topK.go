@@ -0,0 +1,54 @@
+package heap
+
+import (
+	"sort"
+)
+
+/*
+ * Top-K
+ * 用容量为k的堆保留最大的k个元素，堆顶为当前第k大
+ */
+
+type TopK[T any] struct {
+	heap []T
+	k    int
+	less func(a, b T) bool
+}
+
+//创建保留最大k个元素的Top-K，less(a, b)为true表示a小于b
+//k<=0时按1处理
+func NewTopK[T any](k int, less func(a, b T) bool) *TopK[T] {
+	if k <= 0 {
+		k = 1
+	}
+	return &TopK[T]{
+		heap: make([]T, 0, k),
+		k:    k,
+		less: less,
+	}
+}
+
+//加入元素，O(log k)
+func (t *TopK[T]) Put(data T) {
+	if len(t.heap) < t.k {
+		t.heap = append(t.heap, data)
+		up(t.heap, len(t.heap)-1, t.less)
+	} else if t.less(t.heap[0], data) {
+		t.heap[0] = data
+		down(t.heap, 0, len(t.heap), t.less)
+	}
+}
+
+//按从大到小排列的结果
+func (t *TopK[T]) Result() []T {
+	res := append([]T(nil), t.heap...)
+	sort.Slice(res, func(i, j int) bool {
+		return t.less(res[j], res[i])
+	})
+	return res
+}
+
+//已保留的元素个数
+func (t *TopK[T]) Len() int {
+	return len(t.heap)
+}
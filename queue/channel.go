@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"sync"
+)
+
+/*
+ * 通道适配器
+ * 把队列包装成一对通道，Out按入队顺序输出
+ * 无界队列得到无界缓冲的通道，In永不阻塞（除非内部goroutine来不及接收）；
+ * 有界队列满时In暂停接收，直到Out送出元素腾出空间
+ * 缓冲区只通过Push(data)和Pop()访问，带优先级参数的PriorityQueue、mlqueue
+ * 以及Pop返回id的disk_queue不能直接使用，需要调用者自行包装
+ */
+
+//Push不会失败的无界队列，queue、squeue、msqueue均满足
+type Buffer interface {
+	Push(data interface{})
+	Pop() (interface{}, error)
+}
+
+//Push在满时返回error的有界队列，cqueue、scqueue、mpmcqueue、spscqueue均满足
+type BoundedBuffer interface {
+	Push(data interface{}) error
+	Pop() (interface{}, error)
+}
+
+//把Buffer当作永远不满的BoundedBuffer
+type unbounded struct {
+	Buffer
+}
+
+func (b unbounded) Push(data interface{}) error {
+	b.Buffer.Push(data)
+	return nil
+}
+
+type chan_queue struct {
+	in   chan interface{}
+	out  chan interface{}
+	done chan struct{} //Close时关闭，通知内部goroutine退出
+	exit chan struct{} //内部goroutine退出时关闭
+	buf  BoundedBuffer
+	once sync.Once //关闭in
+	stop sync.Once //关闭done
+}
+
+//创建无界通道，未指定缓冲区时使用squeue
+func NewChanQueue(buf ...Buffer) *chan_queue {
+	if len(buf) > 0 && buf[0] != nil {
+		return newChanQueue(unbounded{buf[0]})
+	}
+	return newChanQueue(unbounded{NewSQueue()})
+}
+
+//以有界队列为缓冲区创建通道，缓冲区满时In阻塞
+func NewBoundedChanQueue(buf BoundedBuffer) *chan_queue {
+	return newChanQueue(buf)
+}
+
+func newChanQueue(buf BoundedBuffer) *chan_queue {
+	c := &chan_queue{
+		in:   make(chan interface{}),
+		out:  make(chan interface{}),
+		done: make(chan struct{}),
+		exit: make(chan struct{}),
+		buf:  buf,
+	}
+	go c.run()
+	return c
+}
+
+//输入通道
+func (c *chan_queue) In() chan<- interface{} {
+	return c.in
+}
+
+//输出通道，CloseInput后缓冲区中的元素全部输出完毕时或Close后关闭
+func (c *chan_queue) Out() <-chan interface{} {
+	return c.out
+}
+
+//关闭输入通道，之后不能再向In发送，可重复调用
+//缓冲区中剩余的元素仍从Out输出，消费者须读完Out内部goroutine才会退出
+func (c *chan_queue) CloseInput() {
+	c.once.Do(func() {
+		close(c.in)
+	})
+}
+
+//关闭输入通道并立即停止内部goroutine，缓冲区中剩余的元素被丢弃，可重复调用
+//返回时Out已关闭，不依赖消费者读完Out
+func (c *chan_queue) Close() {
+	c.CloseInput()
+	c.stop.Do(func() {
+		close(c.done)
+	})
+	<-c.exit
+}
+
+func (c *chan_queue) run() {
+	defer close(c.exit)
+	defer close(c.out)
+	in := c.in
+	var next, held interface{} //next为待输出的元素，held为缓冲区满时暂存的输入
+	pending, holding := false, false
+	for {
+		if !pending {
+			if data, err := c.buf.Pop(); err == nil {
+				next, pending = data, true
+			}
+		}
+		if holding && c.buf.Push(held) == nil {
+			held, holding = nil, false
+		}
+		var out chan interface{}
+		if pending {
+			out = c.out
+		} else if in == nil && !holding {
+			return
+		}
+		recv := in
+		if holding {
+			recv = nil
+		}
+		select {
+		case data, ok := <-recv:
+			if !ok {
+				in = nil
+			} else if c.buf.Push(data) != nil {
+				held, holding = data, true
+			}
+		case out <- next:
+			next, pending = nil, false
+		case <-c.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+//读完Out，返回读到的元素
+func drain(t *testing.T, out <-chan interface{}) []interface{} {
+	t.Helper()
+	res := []interface{}{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case data, ok := <-out:
+			if !ok {
+				return res
+			}
+			res = append(res, data)
+		case <-timeout:
+			t.Fatalf("Out not closed, got %v", res)
+		}
+	}
+}
+
+//CloseInput后缓冲区中的元素按序输出完毕，Out随之关闭
+func TestChanQueueCloseInput(t *testing.T) {
+	c := NewChanQueue()
+	for i := 0; i < 100; i++ {
+		c.In() <- i
+	}
+	c.CloseInput()
+	c.CloseInput()
+	res := drain(t, c.Out())
+	if len(res) != 100 {
+		t.Fatalf("got %d items, want 100", len(res))
+	}
+	for i, data := range res {
+		if data != i {
+			t.Fatalf("item %d = %v", i, data)
+		}
+	}
+	within(t, "Close", c.Close)
+}
+
+//有界缓冲区满时In阻塞，Out送出元素后恢复接收，CloseInput后仍按序输出
+func TestBoundedChanQueue(t *testing.T) {
+	q, _ := NewCQueue(2)
+	c := NewBoundedChanQueue(q)
+	go func() {
+		for i := 0; i < 50; i++ {
+			c.In() <- i
+		}
+		c.CloseInput()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case c.In() <- -1:
+		t.Fatal("In accepted an item while the buffer was full")
+	default:
+	}
+	res := drain(t, c.Out())
+	if len(res) != 50 {
+		t.Fatalf("got %d items, want 50", len(res))
+	}
+	for i, data := range res {
+		if data != i {
+			t.Fatalf("item %d = %v", i, data)
+		}
+	}
+}
+
+//消费者正在读Out时Close，Out被关闭，消费者不会永远阻塞
+func TestChanQueueCloseWhileReading(t *testing.T) {
+	c := NewChanQueue()
+	for i := 0; i < 10; i++ {
+		c.In() <- i
+	}
+	read := make(chan []interface{})
+	go func() {
+		res := []interface{}{}
+		for data := range c.Out() {
+			res = append(res, data)
+			if len(res) == 3 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		read <- res
+	}()
+	time.Sleep(5 * time.Millisecond)
+	within(t, "Close", c.Close)
+	select {
+	case res := <-read:
+		for i, data := range res {
+			if data != i {
+				t.Fatalf("item %d = %v", i, data)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consumer still blocked after Close")
+	}
+	within(t, "second Close", c.Close)
+}
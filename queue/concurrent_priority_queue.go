@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"sync"
+)
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+优先队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+type SPriorityQueue[T any] struct {
+	pq   PriorityQueue[T]
+	lock sync.RWMutex
+}
+
+//创建并发安全的优先队列
+func NewSPriorityQueue[T any]() *SPriorityQueue[T] {
+	return &SPriorityQueue[T]{}
+}
+
+//入队
+func (q *SPriorityQueue[T]) Push(data T, priority int) {
+	q.lock.Lock()
+	q.pq.Push(data, priority)
+	q.lock.Unlock()
+}
+
+//入队，返回的句柄可用于Remove
+func (q *SPriorityQueue[T]) Insert(data T, priority int) *pq_item[T] {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.pq.Insert(data, priority)
+}
+
+//删除尚未出队的元素，成功时返回true
+func (q *SPriorityQueue[T]) Remove(item *pq_item[T]) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.pq.Remove(item)
+}
+
+//出队
+func (q *SPriorityQueue[T]) Pop() (T, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.pq.Pop()
+}
+
+//出队，同时返回优先级
+func (q *SPriorityQueue[T]) PopWithPriority() (T, int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.pq.PopWithPriority()
+}
+
+//取队头
+func (q *SPriorityQueue[T]) Head() (T, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.pq.Head()
+}
+
+//队列长度
+func (q *SPriorityQueue[T]) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.pq.Len()
+}
+
+//队列是否为空
+func (q *SPriorityQueue[T]) Empty() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.pq.Empty()
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+多级队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+type smlqueue struct {
+	mq   *mlqueue
+	lock sync.RWMutex
+}
+
+//创建并发安全的多级队列，级数为len(weights)，weights[i]为第i级的权重
+func NewSMultiLevelQueue(weights ...int) (*smlqueue, error) {
+	mq, err := NewMultiLevelQueue(weights...)
+	if err != nil {
+		return nil, err
+	}
+	return &smlqueue{mq: mq}, nil
+}
+
+//入队到第level级
+func (q *smlqueue) Push(data interface{}, level int) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.mq.Push(data, level)
+}
+
+//出队
+func (q *smlqueue) Pop() (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.mq.Pop()
+}
+
+//出队，同时返回所在级别
+func (q *smlqueue) PopWithLevel() (interface{}, int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.mq.PopWithLevel()
+}
+
+//级数
+func (q *smlqueue) Levels() int {
+	return q.mq.Levels()
+}
+
+//第level级的元素个数
+func (q *smlqueue) LevelLen(level int) int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.mq.LevelLen(level)
+}
+
+//队列长度
+func (q *smlqueue) Len() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.mq.Len()
+}
+
+//队列是否为空
+func (q *smlqueue) Empty() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.mq.Empty()
+}
@@ -2,48 +2,81 @@ package queue
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 )
 
+var ErrClosed = errors.New("Queue is closed.")
+
 /*-+-+-+-+-+-+-+-+-+-+-+-+-+-+队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
 
+//环形缓冲区实现，出队的槽位会被释放，长期使用内存占用稳定
 type squeue struct {
-	queue []interface{}
-	lock  sync.RWMutex
+	ring
+	lock sync.RWMutex
 }
 
 //入队
 func (q *squeue) Push(data interface{}) {
 	q.lock.Lock()
-	q.queue = append(q.queue, data)
+	q.pushBack(data)
 	q.lock.Unlock()
 }
 
 //出队
 func (q *squeue) Pop() (interface{}, error) {
 	q.lock.Lock()
-	if len(q.queue) == 0 {
+	if q.count == 0 {
 		q.lock.Unlock()
 		return nil, errors.New("Pop with empty queue.")
 	} else {
-		data := q.queue[0]
-		q.queue = q.queue[1:]
+		data := q.popFront()
 		q.lock.Unlock()
 		return data, nil
 	}
 }
 
+//批量入队
+func (q *squeue) PushAll(items ...interface{}) {
+	q.lock.Lock()
+	q.pushAll(items)
+	q.lock.Unlock()
+}
+
+//批量出队，最多取n个
+func (q *squeue) PopN(n int) []interface{} {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if n > q.count {
+		n = q.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := make([]interface{}, n)
+	q.drainTo(res)
+	return res
+}
+
+//出队到dst，最多取len(dst)个，返回取出的个数
+func (q *squeue) DrainTo(dst []interface{}) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.drainTo(dst)
+}
+
 //取队头
 func (q *squeue) Head() (interface{}, error) {
 	q.lock.RLock()
-	if len(q.queue) == 0 {
+	if q.count == 0 {
 		q.lock.RUnlock()
 		return nil, fmt.Errorf("Empty queue error.")
 	} else {
-		data := q.queue[0]
+		data := q.at(0)
 		q.lock.RUnlock()
 		return data, nil
 	}
@@ -53,18 +86,14 @@ func (q *squeue) Head() (interface{}, error) {
 func (q *squeue) Len() int {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
-	return len(q.queue)
+	return q.count
 }
 
 //队列是否为空
 func (q *squeue) Empty() bool {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
-	if len(q.queue) > 0 {
-		return false
-	} else {
-		return true
-	}
+	return q.count == 0
 }
 
 func (q *squeue) String() string {
@@ -73,8 +102,8 @@ func (q *squeue) String() string {
 	}
 	str := ""
 	q.lock.RLock()
-	for _, v := range q.queue {
-		str += fmt.Sprintf("%v ", v)
+	for i := 0; i < q.count; i++ {
+		str += fmt.Sprintf("%v ", q.at(i))
 	}
 	q.lock.RUnlock()
 	buf := bytes.NewBufferString(strings.Repeat("_", len(str)))
@@ -87,41 +116,39 @@ func (q *squeue) String() string {
 
 //创建队列
 func NewSQueue() *squeue {
-	return &squeue{
-		queue: []interface{}{},
-	}
+	return &squeue{}
 }
 
 /*-+-+-+-+-+-+-+-+-+-+-+-+-+-+双端队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
 
+//环形缓冲区实现，两端操作均摊O(1)
 type sdqueue struct {
-	queue []interface{}
-	lock  sync.RWMutex
+	ring
+	lock sync.RWMutex
 }
 
 //在队头插入
 func (q *sdqueue) PushFront(data interface{}) {
 	q.lock.Lock()
-	q.queue = append([]interface{}{data}, q.queue...)
+	q.pushFront(data)
 	q.lock.Unlock()
 }
 
 //在队尾插入
 func (q *sdqueue) PushBack(data interface{}) {
 	q.lock.Lock()
-	q.queue = append(q.queue, data)
+	q.pushBack(data)
 	q.lock.Unlock()
 }
 
 //从队头出队
 func (q *sdqueue) PopFront() (interface{}, error) {
 	q.lock.Lock()
-	if len(q.queue) == 0 {
+	if q.count == 0 {
 		q.lock.Unlock()
 		return nil, errors.New("Pop with empty queue")
 	} else {
-		data := q.queue[0]
-		q.queue = q.queue[1:]
+		data := q.popFront()
 		q.lock.Unlock()
 		return data, nil
 	}
@@ -130,13 +157,11 @@ func (q *sdqueue) PopFront() (interface{}, error) {
 //从队尾出队
 func (q *sdqueue) PopBack() (interface{}, error) {
 	q.lock.Lock()
-	if len(q.queue) == 0 {
+	if q.count == 0 {
 		q.lock.Unlock()
 		return nil, errors.New("Pop with empty queue")
 	} else {
-		index := len(q.queue) - 1
-		data := q.queue[index]
-		q.queue = q.queue[0:index]
+		data := q.popBack()
 		q.lock.Unlock()
 		return data, nil
 	}
@@ -145,11 +170,11 @@ func (q *sdqueue) PopBack() (interface{}, error) {
 //取队头
 func (q *sdqueue) Head() (interface{}, error) {
 	q.lock.RLock()
-	if len(q.queue) == 0 {
+	if q.count == 0 {
 		q.lock.RUnlock()
 		return nil, errors.New("Queue is Empty")
 	} else {
-		data := q.queue[0]
+		data := q.at(0)
 		q.lock.RUnlock()
 		return data, nil
 	}
@@ -158,33 +183,38 @@ func (q *sdqueue) Head() (interface{}, error) {
 //取队尾
 func (q *sdqueue) Tail() (interface{}, error) {
 	q.lock.RLock()
-	if len(q.queue) == 0 {
+	if q.count == 0 {
 		q.lock.RUnlock()
 		return nil, errors.New("Queue is Empty")
 	} else {
-		index := len(q.queue) - 1
-		data := q.queue[index]
+		data := q.at(q.count - 1)
 		q.lock.RUnlock()
 		return data, nil
 	}
 }
 
+//取从队头数第i个元素
+func (q *sdqueue) At(i int) (interface{}, error) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if i < 0 || i >= q.count {
+		return nil, errors.New("Index out of range.")
+	}
+	return q.at(i), nil
+}
+
 //队列长度
 func (q *sdqueue) Len() int {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
-	return len(q.queue)
+	return q.count
 }
 
 //队列是否为空
 func (q *sdqueue) Empty() bool {
 	q.lock.RLock()
 	defer q.lock.RUnlock()
-	if len(q.queue) > 0 {
-		return false
-	} else {
-		return true
-	}
+	return q.count == 0
 }
 
 func (q *sdqueue) String() string {
@@ -193,8 +223,8 @@ func (q *sdqueue) String() string {
 	}
 	str := ""
 	q.lock.RLock()
-	for _, v := range q.queue {
-		str += fmt.Sprintf("%v ", v)
+	for i := 0; i < q.count; i++ {
+		str += fmt.Sprintf("%v ", q.at(i))
 	}
 	q.lock.RUnlock()
 	buf := bytes.NewBufferString(strings.Repeat("_", len(str)))
@@ -207,9 +237,7 @@ func (q *sdqueue) String() string {
 
 //创建双端队列
 func NewSDQueue() *sdqueue {
-	return &sdqueue{
-		queue: []interface{}{},
-	}
+	return &sdqueue{}
 }
 
 /*-+-+-+-+-+-+-+-+-+-+-+-+-+-+循环队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
@@ -219,25 +247,146 @@ func NewSDQueue() *sdqueue {
 // head[0]            tail[3]
 
 type scqueue struct {
-	queue []interface{}
-	head  int
-	tail  int
-	count int
-	size  int
-	lock  sync.RWMutex
+	queue      []interface{}
+	head       int
+	tail       int
+	count      int
+	size       int
+	closed     bool
+	overwrite  bool                   //满时覆盖最早的元素
+	evict      func(data interface{}) //元素被覆盖时的回调，调用时持有锁
+	autoGrow   bool                   //满时容量翻倍
+	autoShrink bool                   //元素不足容量的1/4时容量减半
+	minSize    int                    //自动缩容的下限，即创建时的容量
+	notEmpty   *sync.Cond
+	notFull    *sync.Cond
+	lock       sync.RWMutex
+}
+
+//设置满时覆盖模式
+//开启后队列满时入队会淘汰最早的元素，evict不为nil时以被淘汰的元素调用
+//evict调用时持有队列的锁，不能在其中操作该队列
+func (q *scqueue) SetOverwrite(overwrite bool, evict ...func(data interface{})) {
+	q.lock.Lock()
+	q.overwrite = overwrite
+	q.evict = nil
+	if len(evict) > 0 {
+		q.evict = evict[0]
+	}
+	q.notFull.Broadcast()
+	q.lock.Unlock()
+}
+
+//设置自动伸缩，grow为true时满了自动扩容（优先于覆盖模式），
+//shrink为true时元素不足容量的1/4自动缩容，但不会小于创建时的容量
+func (q *scqueue) SetAutoResize(grow, shrink bool) {
+	q.lock.Lock()
+	q.autoGrow, q.autoShrink = grow, shrink
+	q.notFull.Broadcast()
+	q.lock.Unlock()
+}
+
+//调整容量为newSize并唤醒等待入队者，调用时须持有锁
+//调用者须保证newSize不小于元素个数
+func (q *scqueue) resize(newSize int) {
+	nq := make([]interface{}, newSize, newSize)
+	copyOut(nq[:q.count], q.queue, q.head)
+	q.queue = nq
+	q.head, q.tail, q.size = 0, q.count%newSize, newSize
+	q.notFull.Broadcast()
+}
+
+//自动缩容，调用时须持有锁
+func (q *scqueue) autoResize() {
+	for q.autoShrink && q.size/2 >= q.minSize && q.count <= q.size/4 {
+		q.resize(q.size / 2)
+	}
+}
+
+//淘汰队头的n个元素，调用时须持有锁
+func (q *scqueue) drop(n int) {
+	for ; n > 0; n-- {
+		data := q.queue[q.head]
+		q.queue[q.head] = nil
+		q.head = (q.head + 1) % q.size
+		q.count--
+		if q.evict != nil {
+			q.evict(data)
+		}
+	}
+}
+
+//入队并唤醒一个等待出队者，调用时须持有锁
+//队列满时先自动扩容，覆盖模式下淘汰队头
+func (q *scqueue) put(data interface{}) {
+	if q.count == q.size && q.autoGrow {
+		q.resize(2 * q.size)
+	} else if q.count == q.size {
+		q.drop(1)
+	}
+	q.queue[q.tail] = data
+	q.tail = (q.tail + 1) % q.size
+	q.count++
+	q.notEmpty.Signal()
+}
+
+//出队并唤醒一个等待入队者，调用时须持有锁
+func (q *scqueue) take() interface{} {
+	data := q.queue[q.head]
+	q.queue[q.head] = nil
+	q.head = (q.head + 1) % q.size
+	q.count--
+	q.notFull.Signal()
+	q.autoResize()
+	return data
+}
+
+//在ctx取消时调用wakeup，使阻塞在条件变量上的等待者能检查ctx
+type watcher struct {
+	done chan struct{}
+}
+
+//第一次需要等待时启动监听goroutine，ctx不可取消时不启动
+func (w *watcher) start(ctx context.Context, wakeup func()) {
+	if w.done != nil || ctx.Done() == nil {
+		return
+	}
+	w.done = make(chan struct{})
+	go func(done chan struct{}) {
+		select {
+		case <-ctx.Done():
+			wakeup()
+		case <-done:
+		}
+	}(w.done)
+}
+
+//结束监听
+func (w *watcher) stop() {
+	if w.done != nil {
+		close(w.done)
+	}
+}
+
+//唤醒所有等待者
+func (q *scqueue) wakeup() {
+	q.lock.Lock()
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.lock.Unlock()
 }
 
 //入队
 func (q *scqueue) Push(data interface{}) error {
 	q.lock.Lock()
-	if q.count == q.size {
+	if q.closed {
+		q.lock.Unlock()
+		return ErrClosed
+	} else if q.count == q.size && !q.overwrite && !q.autoGrow {
 		q.lock.Unlock()
 		return errors.New("Queue is full.")
 	} else {
-
-		q.queue[q.tail] = data
-		q.tail = (q.tail + 1) % q.size
-		q.count++
+		q.put(data)
 		q.lock.Unlock()
 		return nil
 	}
@@ -247,17 +396,180 @@ func (q *scqueue) Push(data interface{}) error {
 func (q *scqueue) Pop() (interface{}, error) {
 	q.lock.Lock()
 	if q.count == 0 {
+		closed := q.closed
 		q.lock.Unlock()
+		if closed {
+			return nil, ErrClosed
+		}
 		return nil, errors.New("Queue is empty.")
 	} else {
-		data := q.queue[q.head]
-		q.head = (q.head + 1) % q.size
-		q.count--
+		data := q.take()
 		q.lock.Unlock()
 		return data, nil
 	}
 }
 
+//批量入队，剩余空间不足时不入队并返回error
+//覆盖模式下淘汰最早的元素，items比容量还多时只保留最后的部分
+func (q *scqueue) PushAll(items ...interface{}) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.autoGrow {
+		q.grow(len(items))
+	}
+	if q.count+len(items) > q.size {
+		if !q.overwrite {
+			return errors.New("Queue is full.")
+		}
+		if len(items) > q.size {
+			q.drop(q.count)
+			skip := items[:len(items)-q.size]
+			items = items[len(items)-q.size:]
+			for _, data := range skip {
+				if q.evict != nil {
+					q.evict(data)
+				}
+			}
+		} else {
+			q.drop(q.count + len(items) - q.size)
+		}
+	}
+	copyIn(q.queue, q.tail, items)
+	q.tail = (q.tail + len(items)) % q.size
+	q.count += len(items)
+	q.notEmpty.Broadcast()
+	return nil
+}
+
+//批量出队，最多取n个
+func (q *scqueue) PopN(n int) []interface{} {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if n > q.count {
+		n = q.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := make([]interface{}, n)
+	q.drainTo(res)
+	return res
+}
+
+//出队到dst，最多取len(dst)个，返回取出的个数
+func (q *scqueue) DrainTo(dst []interface{}) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.drainTo(dst)
+}
+
+//调用时须持有锁
+func (q *scqueue) drainTo(dst []interface{}) int {
+	n := len(dst)
+	if n > q.count {
+		n = q.count
+	}
+	copyOut(dst[:n], q.queue, q.head)
+	q.head = (q.head + n) % q.size
+	q.count -= n
+	if n > 0 {
+		q.notFull.Broadcast()
+	}
+	q.autoResize()
+	return n
+}
+
+//入队，队列满时阻塞直到有空位、ctx取消或队列关闭
+func (q *scqueue) PutWait(ctx context.Context, data interface{}) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var w watcher
+	defer w.stop()
+	for !q.closed && !q.overwrite && !q.autoGrow && q.count == q.size {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w.start(ctx, q.wakeup)
+		q.notFull.Wait()
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	q.put(data)
+	return nil
+}
+
+//出队，队列空时阻塞直到有元素、ctx取消或队列关闭
+//关闭后仍可取出剩余元素，取完后返回ErrClosed
+func (q *scqueue) TakeWait(ctx context.Context) (interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var w watcher
+	defer w.stop()
+	for !q.closed && q.count == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		w.start(ctx, q.wakeup)
+		q.notEmpty.Wait()
+	}
+	if q.count == 0 {
+		return nil, ErrClosed
+	}
+	return q.take(), nil
+}
+
+//入队，最多等待timeout
+func (q *scqueue) Offer(data interface{}, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := q.PutWait(ctx, data)
+	if err == context.DeadlineExceeded {
+		return errors.New("Queue is full.")
+	}
+	return err
+}
+
+//出队，最多等待timeout
+func (q *scqueue) Poll(timeout time.Duration) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	data, err := q.TakeWait(ctx)
+	if err == context.DeadlineExceeded {
+		return nil, errors.New("Queue is empty.")
+	}
+	return data, err
+}
+
+//关闭队列，唤醒所有等待者，之后不能再入队
+func (q *scqueue) Close() {
+	q.lock.Lock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.lock.Unlock()
+}
+
+//最近入队的n个元素，按入队顺序排列
+func (q *scqueue) Last(n int) []interface{} {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if n > q.count {
+		n = q.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := make([]interface{}, n)
+	for i := range res {
+		res[i] = q.queue[(q.head+q.count-n+i)%q.size]
+	}
+	return res
+}
+
 //取队头
 func (q *scqueue) Head() (interface{}, error) {
 	q.lock.RLock()
@@ -292,23 +604,57 @@ func (q *scqueue) Full() bool {
 	return q.count == q.size
 }
 
+//队列容量
+func (q *scqueue) Cap() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.size
+}
+
+//保证至少还能入队n个元素，需要扩容时容量至少翻倍
+func (q *scqueue) Grow(n int) {
+	q.lock.Lock()
+	q.grow(n)
+	q.lock.Unlock()
+}
+
+//调用时须持有锁
+func (q *scqueue) grow(n int) {
+	if n <= q.size-q.count {
+		return
+	}
+	newSize := 2 * q.size
+	if newSize < q.count+n {
+		newSize = q.count + n
+	}
+	q.resize(newSize)
+}
+
+//容量缩小到刚好容纳现有元素
+func (q *scqueue) Shrink() {
+	q.lock.Lock()
+	if q.count == 0 {
+		q.resize(1)
+	} else if q.count < q.size {
+		q.resize(q.count)
+	}
+	q.lock.Unlock()
+}
+
 //重新设置循环队列大小
+//newSize小于元素个数时，覆盖模式下淘汰最早的元素，否则返回error
 func (q *scqueue) Resize(newSize int) (int, error) {
-	q.lock.RLock()
-	if newSize <= q.count {
-		q.lock.RUnlock()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if newSize <= 0 || (newSize < q.count && !q.overwrite) {
 		return q.size, errors.New("New size is too small.")
-	} else {
-		old := q.size
-		nq := make([]interface{}, newSize, newSize)
-		for i := 0; i < q.count; i++ {
-			nq[i] = q.queue[(q.head+i)%q.size]
-		}
-		q.queue = nq
-		q.head, q.tail, q.size = 0, q.count, newSize
-		q.lock.RUnlock()
-		return old, nil
 	}
+	old := q.size
+	if newSize < q.count {
+		q.drop(q.count - newSize)
+	}
+	q.resize(newSize)
+	return old, nil
 }
 
 func (q *scqueue) String() string {
@@ -335,11 +681,15 @@ func NewSCQueue(size int) (*scqueue, error) {
 	if size <= 0 {
 		return nil, fmt.Errorf("can't create loop queue with zero size.")
 	}
-	return &scqueue{
-		queue: make([]interface{}, size, size),
-		head:  0,
-		tail:  0,
-		count: 0,
-		size:  size,
-	}, nil
+	q := &scqueue{
+		queue:   make([]interface{}, size, size),
+		head:    0,
+		tail:    0,
+		count:   0,
+		size:    size,
+		minSize: size,
+	}
+	q.notEmpty = sync.NewCond(&q.lock)
+	q.notFull = sync.NewCond(&q.lock)
+	return q, nil
 }
@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newSC(t *testing.T, size int) *scqueue {
+	t.Helper()
+	q, err := NewSCQueue(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+//等待f返回，超时则失败
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+//等待goroutine数回落到n以下，确认监听goroutine已退出
+func settle(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left, want at most %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+//阻塞中的TakeWait和PutWait在ctx取消后返回ctx.Err()，监听goroutine随之退出
+func TestSCQueueWaitContext(t *testing.T) {
+	base := runtime.NumGoroutine()
+	q := newSC(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	var takeErr error
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	within(t, "TakeWait", func() { _, takeErr = q.TakeWait(ctx) })
+	if takeErr != context.Canceled {
+		t.Fatalf("TakeWait = %v, want context.Canceled", takeErr)
+	}
+
+	q.Push(1)
+	ctx, cancel = context.WithCancel(context.Background())
+	var putErr error
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	within(t, "PutWait", func() { putErr = q.PutWait(ctx, 2) })
+	if putErr != context.Canceled {
+		t.Fatalf("PutWait = %v, want context.Canceled", putErr)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", q.Len())
+	}
+	settle(t, base)
+}
+
+//等到元素后返回的TakeWait也会结束监听goroutine
+func TestSCQueueWatcherStops(t *testing.T) {
+	base := runtime.NumGoroutine()
+	q := newSC(t, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.TakeWait(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := q.PutWait(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	within(t, "TakeWait", wg.Wait)
+	settle(t, base)
+}
+
+//关闭后等待者被唤醒，剩余元素仍可取出，取完后返回ErrClosed
+func TestSCQueueClose(t *testing.T) {
+	full := newSC(t, 1)
+	full.Push(0)
+	empty := newSC(t, 1)
+	var putErr, takeErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		putErr = full.PutWait(context.Background(), 1)
+	}()
+	go func() {
+		defer wg.Done()
+		_, takeErr = empty.TakeWait(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	full.Close()
+	empty.Close()
+	within(t, "waiters", wg.Wait)
+	if putErr != ErrClosed || takeErr != ErrClosed {
+		t.Fatalf("PutWait = %v, TakeWait = %v, want ErrClosed", putErr, takeErr)
+	}
+
+	q := newSC(t, 4)
+	q.PushAll(1, 2, 3)
+	q.Close()
+	if err := q.Push(4); err != ErrClosed {
+		t.Fatalf("Push after Close = %v, want ErrClosed", err)
+	}
+	if err := q.PutWait(context.Background(), 4); err != ErrClosed {
+		t.Fatalf("PutWait after Close = %v, want ErrClosed", err)
+	}
+	for i := 1; i <= 3; i++ {
+		var data interface{}
+		var err error
+		if i%2 == 0 {
+			data, err = q.Poll(time.Second)
+		} else {
+			data, err = q.TakeWait(context.Background())
+		}
+		if err != nil || data != i {
+			t.Fatalf("take %d after Close = %v, %v", i, data, err)
+		}
+	}
+	if _, err := q.TakeWait(context.Background()); err != ErrClosed {
+		t.Fatalf("TakeWait on drained queue = %v, want ErrClosed", err)
+	}
+	if _, err := q.Pop(); err != ErrClosed {
+		t.Fatalf("Pop on drained queue = %v, want ErrClosed", err)
+	}
+}
+
+//Offer和Poll超时返回满和空的错误，而不是ctx的错误
+func TestSCQueueOfferPollTimeout(t *testing.T) {
+	q := newSC(t, 1)
+	start := time.Now()
+	if _, err := q.Poll(20 * time.Millisecond); err == nil || err == ErrClosed || err == context.DeadlineExceeded {
+		t.Fatalf("Poll on empty queue = %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("Poll returned before timeout")
+	}
+	q.Push(1)
+	start = time.Now()
+	if err := q.Offer(2, 20*time.Millisecond); err == nil || err == ErrClosed || err == context.DeadlineExceeded {
+		t.Fatalf("Offer on full queue = %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("Offer returned before timeout")
+	}
+	if data, err := q.Poll(time.Second); err != nil || data != 1 {
+		t.Fatalf("Poll = %v, %v, want 1", data, err)
+	}
+}
+
+//多个生产者PutWait、多个消费者TakeWait，关闭后每个元素恰好取出一次
+func TestSCQueueWaitConcurrent(t *testing.T) {
+	q := newSC(t, 8)
+	const producers, n = 4, 2000
+	var pwg, cwg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		pwg.Add(1)
+		go func(p int) {
+			defer pwg.Done()
+			for i := 0; i < n; i++ {
+				if err := q.PutWait(context.Background(), p*n+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(p)
+	}
+	seen := make([]int, producers*n)
+	var lock sync.Mutex
+	for c := 0; c < 4; c++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for {
+				data, err := q.TakeWait(ctx)
+				if err == ErrClosed {
+					return
+				} else if err != nil {
+					t.Error(err)
+					return
+				}
+				lock.Lock()
+				seen[data.(int)]++
+				lock.Unlock()
+			}
+		}()
+	}
+	pwg.Wait()
+	q.Close()
+	within(t, "consumers", cwg.Wait)
+	for i, c := range seen {
+		if c != 1 {
+			t.Fatalf("item %d taken %d times", i, c)
+		}
+	}
+}
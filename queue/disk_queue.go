@@ -0,0 +1,554 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+持久化队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//元素按顺序追加到目录下的分段日志文件，文件名为段内第一个元素的序号
+//每条记录：长度(4字节)，CRC32(4字节)，编码后的元素
+//Ack过的序号追加到ack文件，重启时重放所有未Ack的元素（包括已Pop未Ack的）
+//除当前写入的段外，元素全部Ack的段会被删除
+//内存中只保存未出队元素的序号和记录位置，Pop时从段文件读出并解码，因此重启前后取出的值一致
+
+//元素与日志记录之间的转换
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+//gob编码，解码后保持元素原来的Go类型
+//结构体等自定义类型须先调用gob.Register
+type GobCodec struct{}
+
+func (GobCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(data []byte) (interface{}, error) {
+	var v interface{}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+//OpenDiskQueue的codec为nil时使用
+var DefaultCodec Codec = GobCodec{}
+
+//默认段大小
+const DefaultSegmentSize = 4 << 20
+
+const (
+	segmentExt  = ".seg"
+	ackFile     = "ack"
+	recordHead  = 8
+	maxRecord   = 1 << 30
+	ackIDLength = 8
+)
+
+var errCorrupt = errors.New("Corrupt queue data.")
+
+//日志文件，测试时可替换为会写入失败的实现
+type logFile interface {
+	Write(b []byte) (int, error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+type disk_segment struct {
+	base  uint64 //段内第一个元素的序号
+	count int
+	acked int
+	size  int64
+}
+
+//未出队元素在段文件中的位置
+type disk_item struct {
+	id     uint64
+	offset int64 //记录在段内的偏移
+	size   int   //编码后的长度
+}
+
+type disk_queue struct {
+	dir      string
+	codec    Codec
+	segSize  int64
+	segments []*disk_segment //按base升序，最后一个为当前写入的段
+	file     logFile         //当前写入的段
+	acks     logFile
+	reader   *os.File //Pop最近读取的段
+	readBase uint64
+	ackCount int //ack文件中的记录数
+	ackStale int //其中属于已删除段的记录数
+	next     uint64
+	unread   ring
+	inflight map[uint64]bool //已Pop未Ack
+	failed   error           //写入失败且无法截回时记录，之后的写操作都返回它
+	closed   bool
+	lock     sync.Mutex
+}
+
+//打开或创建dir下的持久化队列，codec为nil时使用DefaultCodec，
+//segmentSize<=0时使用DefaultSegmentSize
+func OpenDiskQueue(dir string, codec Codec, segmentSize int64) (*disk_queue, error) {
+	if codec == nil {
+		codec = DefaultCodec
+	}
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	q := &disk_queue{
+		dir:      dir,
+		codec:    codec,
+		segSize:  segmentSize,
+		inflight: map[uint64]bool{},
+	}
+	if err := q.replay(); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *disk_queue) segmentPath(base uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", base, segmentExt))
+}
+
+//读取所有段和ack文件，恢复未Ack的元素
+func (q *disk_queue) replay() error {
+	names, err := filepath.Glob(filepath.Join(q.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+	bases := []uint64{}
+	for _, name := range names {
+		var base uint64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(filepath.Base(name), segmentExt), "%d", &base); err != nil {
+			return errCorrupt
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+
+	acked, err := q.readAcks()
+	if err != nil {
+		return err
+	}
+	for k, base := range bases {
+		if base < q.next {
+			return errCorrupt
+		}
+		last := k == len(bases)-1
+		seg, items, err := q.readSegment(base, last)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if acked[item.id] {
+				seg.acked++
+			} else {
+				q.unread.pushBack(item)
+			}
+		}
+		q.segments = append(q.segments, seg)
+		q.next = base + uint64(seg.count)
+	}
+	if len(q.segments) == 0 {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.OpenFile(q.segmentPath(q.active().base), os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		q.file = f
+	}
+	q.ackStale = q.ackCount
+	for _, seg := range q.segments {
+		q.ackStale -= seg.acked
+	}
+	return q.compact()
+}
+
+//读取ack文件，末尾不完整的记录被忽略
+func (q *disk_queue) readAcks() (map[uint64]bool, error) {
+	f, err := os.OpenFile(filepath.Join(q.dir, ackFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	q.acks = f
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	n := len(data) / ackIDLength
+	if err := f.Truncate(int64(n * ackIDLength)); err != nil {
+		return nil, err
+	}
+	acked := make(map[uint64]bool, n)
+	for i := 0; i < n; i++ {
+		acked[binary.LittleEndian.Uint64(data[i*ackIDLength:])] = true
+	}
+	q.ackCount = n
+	return acked, nil
+}
+
+//读取一个段，最后一个段末尾不完整或损坏的记录会被截掉
+func (q *disk_queue) readSegment(base uint64, last bool) (*disk_segment, []disk_item, error) {
+	path := q.segmentPath(base)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	seg := &disk_segment{base: base}
+	items := []disk_item{}
+	off := 0
+	for off < len(data) {
+		if len(data)-off < recordHead {
+			break
+		}
+		size := int(binary.LittleEndian.Uint32(data[off:]))
+		sum := binary.LittleEndian.Uint32(data[off+4:])
+		if size > len(data)-off-recordHead {
+			break
+		}
+		if crc32.ChecksumIEEE(data[off+recordHead:off+recordHead+size]) != sum {
+			break
+		}
+		items = append(items, disk_item{base + uint64(seg.count), int64(off), size})
+		seg.count++
+		off += recordHead + size
+	}
+	if off < len(data) {
+		if !last {
+			return nil, nil, errCorrupt
+		}
+		if err := os.Truncate(path, int64(off)); err != nil {
+			return nil, nil, err
+		}
+	}
+	seg.size = int64(off)
+	return seg, items, nil
+}
+
+func (q *disk_queue) active() *disk_segment {
+	return q.segments[len(q.segments)-1]
+}
+
+//以下一个序号新建段作为当前写入的段
+func (q *disk_queue) rotate() error {
+	f, err := os.OpenFile(q.segmentPath(q.next), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file = f
+	q.segments = append(q.segments, &disk_segment{base: q.next})
+	return nil
+}
+
+//序号id所在的段，已删除时返回nil
+func (q *disk_queue) segment(id uint64) *disk_segment {
+	i := sort.Search(len(q.segments), func(i int) bool {
+		return q.segments[i].base > id
+	}) - 1
+	if i < 0 || id >= q.segments[i].base+uint64(q.segments[i].count) {
+		return nil
+	}
+	return q.segments[i]
+}
+
+//入队，元素写入日志后返回
+func (q *disk_queue) Push(data interface{}) error {
+	payload, err := q.codec.Encode(data)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxRecord {
+		return errors.New("Item is too large.")
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.failed != nil {
+		return q.failed
+	}
+	seg := q.active()
+	if seg.size > 0 && seg.size+int64(recordHead+len(payload)) > q.segSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+		if err := q.compact(); err != nil {
+			return err
+		}
+		seg = q.active()
+	}
+	buf := make([]byte, recordHead+len(payload))
+	binary.LittleEndian.PutUint32(buf, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(payload))
+	copy(buf[recordHead:], payload)
+	if _, err := q.file.Write(buf); err != nil {
+		return q.rollback(q.file, seg.size, err)
+	}
+	q.unread.pushBack(disk_item{q.next, seg.size, len(payload)})
+	seg.size += int64(len(buf))
+	seg.count++
+	q.next++
+	return nil
+}
+
+//出队，返回用于Ack的序号
+//Ack之前元素仍保留在日志中，重启后会被重新投递
+//记录损坏或解码失败时元素同样出队，返回其序号和error，调用者可以Ack将其丢弃
+func (q *disk_queue) Pop() (uint64, interface{}, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return 0, nil, ErrClosed
+	}
+	if q.unread.count == 0 {
+		return 0, nil, errors.New("Pop with empty queue.")
+	}
+	item := q.unread.at(0).(disk_item)
+	payload, err := q.read(item)
+	if err != nil && err != errCorrupt { //读文件失败时元素留在队头
+		return 0, nil, err
+	}
+	q.unread.popFront()
+	q.inflight[item.id] = true
+	if err != nil {
+		return item.id, nil, err
+	}
+	data, err := q.codec.Decode(payload)
+	return item.id, data, err
+}
+
+//从段文件读出元素编码后的内容并校验，调用时须持有锁
+func (q *disk_queue) read(item disk_item) ([]byte, error) {
+	seg := q.segment(item.id)
+	if seg == nil {
+		return nil, errCorrupt
+	}
+	if q.reader == nil || q.readBase != seg.base {
+		f, err := os.Open(q.segmentPath(seg.base))
+		if err != nil {
+			return nil, err
+		}
+		if q.reader != nil {
+			q.reader.Close()
+		}
+		q.reader, q.readBase = f, seg.base
+	}
+	buf := make([]byte, recordHead+item.size)
+	if _, err := q.reader.ReadAt(buf, item.offset); err != nil {
+		return nil, err
+	}
+	payload := buf[recordHead:]
+	if binary.LittleEndian.Uint32(buf[4:]) != crc32.ChecksumIEEE(payload) {
+		return nil, errCorrupt
+	}
+	return payload, nil
+}
+
+//写入失败时把f截回size，去掉写了一半的记录，调用时须持有锁
+//截断也失败时队列进入失败状态，之后的Push和Ack都返回error
+func (q *disk_queue) rollback(f logFile, size int64, err error) error {
+	if e := f.Truncate(size); e != nil {
+		q.failed = fmt.Errorf("Queue is broken, write failed: %v, truncate failed: %v.", err, e)
+	}
+	return err
+}
+
+//确认元素已处理完毕，之后不会再被投递
+func (q *disk_queue) Ack(id uint64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.failed != nil {
+		return q.failed
+	}
+	if !q.inflight[id] {
+		return errors.New("Unknown id.")
+	}
+	var buf [ackIDLength]byte
+	binary.LittleEndian.PutUint64(buf[:], id)
+	if _, err := q.acks.Write(buf[:]); err != nil {
+		return q.rollback(q.acks, int64(q.ackCount*ackIDLength), err)
+	}
+	delete(q.inflight, id)
+	q.ackCount++
+	seg := q.segment(id)
+	seg.acked++
+	if seg.acked == seg.count && seg != q.active() {
+		return q.compact()
+	}
+	return nil
+}
+
+//删除全部Ack的段，并从ack文件中去掉这些段的记录
+func (q *disk_queue) Compact() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if err := q.compact(); err != nil {
+		return err
+	}
+	if q.ackStale > 0 {
+		return q.rewriteAcks()
+	}
+	return nil
+}
+
+//删除全部Ack的段，调用时须持有锁
+//ack文件中过时的记录达到一半时重写ack文件
+func (q *disk_queue) compact() error {
+	kept := q.segments[:0]
+	for k, seg := range q.segments {
+		if k < len(q.segments)-1 && seg.acked == seg.count {
+			if q.reader != nil && q.readBase == seg.base {
+				q.reader.Close()
+				q.reader = nil
+			}
+			if err := os.Remove(q.segmentPath(seg.base)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			q.ackStale += seg.acked
+			continue
+		}
+		kept = append(kept, seg)
+	}
+	for k := len(kept); k < len(q.segments); k++ {
+		q.segments[k] = nil
+	}
+	q.segments = kept
+	if q.ackStale > 0 && 2*q.ackStale >= q.ackCount {
+		return q.rewriteAcks()
+	}
+	return nil
+}
+
+//只保留仍存在的段中的ack记录，先写临时文件再替换，调用时须持有锁
+func (q *disk_queue) rewriteAcks() error {
+	data, err := os.ReadFile(filepath.Join(q.dir, ackFile))
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 0, len(data))
+	for i := 0; i+ackIDLength <= len(data); i += ackIDLength {
+		if q.segment(binary.LittleEndian.Uint64(data[i:])) != nil {
+			buf = append(buf, data[i:i+ackIDLength]...)
+		}
+	}
+	tmp := filepath.Join(q.dir, ackFile+".tmp")
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	q.acks.Close()
+	path := filepath.Join(q.dir, ackFile)
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	q.acks = f
+	q.ackCount, q.ackStale = len(buf)/ackIDLength, 0
+	return nil
+}
+
+//未出队的元素个数
+func (q *disk_queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.unread.count
+}
+
+//已出队未Ack的元素个数
+func (q *disk_queue) Inflight() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.inflight)
+}
+
+//队列是否为空（不含已出队未Ack的元素）
+func (q *disk_queue) Empty() bool {
+	return q.Len() == 0
+}
+
+//将已写入的数据刷到磁盘
+func (q *disk_queue) Sync() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if err := q.file.Sync(); err != nil {
+		return err
+	}
+	return q.acks.Sync()
+}
+
+//刷盘并关闭文件，可重复调用
+func (q *disk_queue) Close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	err := q.file.Sync()
+	if e := q.acks.Sync(); err == nil {
+		err = e
+	}
+	if e := q.closeFiles(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (q *disk_queue) closeFiles() error {
+	var err error
+	if q.file != nil {
+		err = q.file.Close()
+	}
+	if q.acks != nil {
+		if e := q.acks.Close(); err == nil {
+			err = e
+		}
+	}
+	if q.reader != nil {
+		if e := q.reader.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,330 @@
+package queue
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openDisk(t *testing.T, dir string, codec Codec, segmentSize int64) *disk_queue {
+	t.Helper()
+	q, err := OpenDiskQueue(dir, codec, segmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func popDisk(t *testing.T, q *disk_queue) (uint64, interface{}) {
+	t.Helper()
+	id, data, err := q.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, data
+}
+
+func countFiles(t *testing.T, dir, pattern string) int {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(names)
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.Size()
+}
+
+//重启后已Pop未Ack的元素按原顺序重新投递，值的类型不变
+func TestDiskQueueReplay(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, nil, 64)
+	for i := 0; i < 10; i++ {
+		if err := q.Push(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		id, data := popDisk(t, q)
+		if data != i {
+			t.Fatalf("Pop = %v, want %d", data, i)
+		}
+		if i < 3 {
+			if err := q.Ack(id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := q.Ack(0); err == nil {
+		t.Fatal("second Ack of the same id succeeded")
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openDisk(t, dir, nil, 64)
+	defer q.Close()
+	if q.Len() != 7 || q.Inflight() != 0 {
+		t.Fatalf("after restart Len = %d, Inflight = %d, want 7, 0", q.Len(), q.Inflight())
+	}
+	for i := 3; i < 10; i++ {
+		id, data := popDisk(t, q)
+		if id != uint64(i) || data != i {
+			t.Fatalf("Pop = %d, %v, want %d", id, data, i)
+		}
+	}
+	if err := q.Push("next"); err != nil {
+		t.Fatal(err)
+	}
+	if id, data := popDisk(t, q); id != 10 || data != "next" {
+		t.Fatalf("Pop = %d, %v, want 10, next", id, data)
+	}
+}
+
+//全部Ack的段被删除，Compact后ack文件只保留仍存在的段的记录
+func TestDiskQueueCompaction(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, nil, 128)
+	defer q.Close()
+	for i := 0; i < 100; i++ {
+		q.Push(i)
+	}
+	segments := countFiles(t, dir, "*"+segmentExt)
+	if segments < 5 {
+		t.Fatalf("%d segments, want at least 5", segments)
+	}
+	first, _ := popDisk(t, q)
+	for i := 1; i < 100; i++ {
+		id, _ := popDisk(t, q)
+		if err := q.Ack(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//第一个段还有未Ack的元素，最后一个段正在写入
+	if n := countFiles(t, dir, "*"+segmentExt); n != 2 {
+		t.Fatalf("%d segments after ack, want 2", n)
+	}
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	live := 0
+	for _, seg := range q.segments {
+		live += seg.acked
+	}
+	if size := fileSize(t, filepath.Join(dir, ackFile)); size != int64(live*ackIDLength) {
+		t.Fatalf("ack file has %d bytes, want %d", size, live*ackIDLength)
+	}
+	if err := q.Ack(first); err != nil {
+		t.Fatal(err)
+	}
+	if n := countFiles(t, dir, "*"+segmentExt); n != 1 {
+		t.Fatalf("%d segments after acking everything, want 1", n)
+	}
+}
+
+//夹在两个仍存在的段之间的已删除段，其ack记录在重写时被去掉
+func TestDiskQueueStaleAcks(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, nil, 1) //每个元素一个段
+	for i := 0; i < 3; i++ {
+		q.Push(i)
+	}
+	popDisk(t, q)
+	id, _ := popDisk(t, q)
+	if err := q.Ack(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(t, filepath.Join(dir, ackFile)); size != 0 {
+		t.Fatalf("ack file has %d bytes, want 0", size)
+	}
+	q.Close()
+	q = openDisk(t, dir, nil, 1)
+	defer q.Close()
+	if q.Len() != 2 {
+		t.Fatalf("after restart Len = %d, want 2", q.Len())
+	}
+}
+
+//最后一个段末尾不完整的记录在打开时被截掉，之后可以正常追加
+func TestDiskQueueTornTail(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, nil, 0)
+	for i := 0; i < 3; i++ {
+		q.Push(i)
+	}
+	q.Close()
+	seg := q.segmentPath(0)
+	size := fileSize(t, seg)
+	f, err := os.OpenFile(seg, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{100, 0, 0, 0, 1, 2})
+	f.Close()
+
+	q = openDisk(t, dir, nil, 0)
+	if q.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", q.Len())
+	}
+	if got := fileSize(t, seg); got != size {
+		t.Fatalf("segment has %d bytes, want %d", got, size)
+	}
+	q.Push(3)
+	q.Close()
+	q = openDisk(t, dir, nil, 0)
+	defer q.Close()
+	for i := 0; i < 4; i++ {
+		if _, data := popDisk(t, q); data != i {
+			t.Fatalf("Pop = %v, want %d", data, i)
+		}
+	}
+}
+
+type failCodec struct {
+	GobCodec
+}
+
+func (c failCodec) Decode(data []byte) (interface{}, error) {
+	v, err := c.GobCodec.Decode(data)
+	if v == "bad" {
+		return nil, errors.New("bad item")
+	}
+	return v, err
+}
+
+//解码失败的元素不会卡住队头，Ack后不再投递
+func TestDiskQueueDecodeError(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, failCodec{}, 0)
+	q.Push("bad")
+	q.Push("good")
+	id, _, err := q.Pop()
+	if err == nil {
+		t.Fatal("Pop of undecodable item succeeded")
+	}
+	if _, data := popDisk(t, q); data != "good" {
+		t.Fatalf("Pop = %v, want good", data)
+	}
+	if err := q.Ack(id); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+	q = openDisk(t, dir, failCodec{}, 0)
+	defer q.Close()
+	if _, data := popDisk(t, q); data != "good" {
+		t.Fatalf("Pop = %v, want good", data)
+	}
+}
+
+//只写入一半就失败的文件，truncate为false时截断也失败
+type shortFile struct {
+	logFile
+	truncate bool
+}
+
+func (f shortFile) Write(b []byte) (int, error) {
+	n, _ := f.logFile.Write(b[:len(b)/2])
+	return n, io.ErrShortWrite
+}
+
+func (f shortFile) Truncate(size int64) error {
+	if !f.truncate {
+		return errors.New("truncate failed")
+	}
+	return f.logFile.Truncate(size)
+}
+
+//写了一半的记录被截掉，之后的Push和Ack不受影响，重启后数据完整
+func TestDiskQueueShortWrite(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, nil, 0)
+	q.Push(0)
+	seg, size := q.segmentPath(0), fileSize(t, q.segmentPath(0))
+	file := q.file
+	q.file = shortFile{file, true}
+	if err := q.Push(1); err == nil {
+		t.Fatal("Push with short write succeeded")
+	}
+	if got := fileSize(t, seg); got != size {
+		t.Fatalf("segment has %d bytes after failed Push, want %d", got, size)
+	}
+	q.file = file
+	q.Push(2)
+
+	id, _ := popDisk(t, q)
+	acks := q.acks
+	q.acks = shortFile{acks, true}
+	if err := q.Ack(id); err == nil {
+		t.Fatal("Ack with short write succeeded")
+	}
+	if got := fileSize(t, filepath.Join(dir, ackFile)); got != 0 {
+		t.Fatalf("ack file has %d bytes after failed Ack, want 0", got)
+	}
+	q.acks = acks
+	if err := q.Ack(id); err != nil {
+		t.Fatalf("retrying Ack: %v", err)
+	}
+	q.Close()
+
+	q = openDisk(t, dir, nil, 0)
+	if q.Len() != 1 {
+		t.Fatalf("after restart Len = %d, want 1", q.Len())
+	}
+	if _, data := popDisk(t, q); data != 2 {
+		t.Fatalf("Pop = %v, want 2", data)
+	}
+
+	//截断也失败时队列进入失败状态
+	q.file = shortFile{q.file, false}
+	if err := q.Push(3); err == nil {
+		t.Fatal("Push with short write succeeded")
+	}
+	if err := q.Push(4); err == nil {
+		t.Fatal("Push after failed rollback succeeded")
+	}
+	if err := q.Ack(1); err == nil {
+		t.Fatal("Ack after failed rollback succeeded")
+	}
+	q.Close()
+}
+
+//元素内容不留在内存中，Pop从段文件读取
+//读到损坏的记录时与解码失败一样出队并返回error，Ack后继续取后面的元素
+func TestDiskQueueReadFromSegment(t *testing.T) {
+	dir := t.TempDir()
+	q := openDisk(t, dir, nil, 0)
+	defer q.Close()
+	q.Push("hello")
+	q.Push("world")
+	f, err := os.OpenFile(q.segmentPath(0), os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{0xff}, recordHead)
+	f.Close()
+	id, _, err := q.Pop()
+	if err == nil {
+		t.Fatal("Pop of corrupted record succeeded")
+	}
+	if q.Len() != 1 || q.Inflight() != 1 {
+		t.Fatalf("Len = %d, Inflight = %d, want 1, 1", q.Len(), q.Inflight())
+	}
+	if err := q.Ack(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, data := popDisk(t, q); data != "world" {
+		t.Fatalf("Pop = %v, want world", data)
+	}
+}
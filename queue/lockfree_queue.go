@@ -0,0 +1,246 @@
+package queue
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+//缓存行填充，避免生产者和消费者的计数器伪共享
+type pad [64]byte
+
+//向上取到2的幂
+func ceilPow2(n int) uint64 {
+	size := uint64(1)
+	for size < uint64(n) {
+		size <<= 1
+	}
+	return size
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+无锁有界多生产者多消费者队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//Dmitry Vyukov的有界MPMC队列
+//每个槽位带序号seq：seq == pos 表示可写，seq == pos+1 表示可读
+
+type mpmc_cell struct {
+	seq  atomic.Uint64
+	data interface{}
+}
+
+type mpmcqueue struct {
+	buf  []mpmc_cell
+	mask uint64
+	_    pad
+	enq  atomic.Uint64
+	_    pad
+	deq  atomic.Uint64
+	_    pad
+}
+
+//入队，队列满时返回error
+func (q *mpmcqueue) Push(data interface{}) error {
+	pos := q.enq.Load()
+	for {
+		cell := &q.buf[pos&q.mask]
+		dif := int64(cell.seq.Load() - pos)
+		if dif == 0 {
+			if q.enq.CompareAndSwap(pos, pos+1) {
+				cell.data = data
+				cell.seq.Store(pos + 1)
+				return nil
+			}
+			pos = q.enq.Load()
+		} else if dif < 0 {
+			return errors.New("Queue is full.")
+		} else {
+			pos = q.enq.Load()
+		}
+	}
+}
+
+//出队，队列空时返回error
+func (q *mpmcqueue) Pop() (interface{}, error) {
+	pos := q.deq.Load()
+	for {
+		cell := &q.buf[pos&q.mask]
+		dif := int64(cell.seq.Load() - (pos + 1))
+		if dif == 0 {
+			if q.deq.CompareAndSwap(pos, pos+1) {
+				data := cell.data
+				cell.data = nil
+				cell.seq.Store(pos + q.mask + 1)
+				return data, nil
+			}
+			pos = q.deq.Load()
+		} else if dif < 0 {
+			return nil, errors.New("Queue is empty.")
+		} else {
+			pos = q.deq.Load()
+		}
+	}
+}
+
+//队列长度，并发修改时为近似值
+func (q *mpmcqueue) Len() int {
+	deq := q.deq.Load()
+	enq := q.enq.Load()
+	if enq < deq {
+		return 0
+	}
+	return int(enq - deq)
+}
+
+//队列是否为空
+func (q *mpmcqueue) Empty() bool {
+	return q.Len() == 0
+}
+
+//创建无锁有界队列，容量向上取到2的幂
+func NewMPMCQueue(size int) (*mpmcqueue, error) {
+	if size <= 0 {
+		return nil, errors.New("Can't create queue with zero size.")
+	}
+	n := ceilPow2(size)
+	q := &mpmcqueue{
+		buf:  make([]mpmc_cell, n),
+		mask: n - 1,
+	}
+	for i := range q.buf {
+		q.buf[i].seq.Store(uint64(i))
+	}
+	return q, nil
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+无锁无界链表队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//Michael-Scott队列，head指向哑节点
+//由GC回收节点，不存在ABA问题
+
+type msnode struct {
+	data interface{}
+	next atomic.Pointer[msnode]
+}
+
+type msqueue struct {
+	head atomic.Pointer[msnode]
+	_    pad
+	tail atomic.Pointer[msnode]
+}
+
+//入队
+func (q *msqueue) Push(data interface{}) {
+	n := &msnode{data: data}
+	for {
+		tail := q.tail.Load()
+		next := tail.next.Load()
+		if tail != q.tail.Load() {
+			continue
+		}
+		if next != nil { //帮助推进tail
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, n) {
+			q.tail.CompareAndSwap(tail, n)
+			return
+		}
+	}
+}
+
+//出队，队列空时返回error
+func (q *msqueue) Pop() (interface{}, error) {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		next := head.next.Load()
+		if head != q.head.Load() {
+			continue
+		}
+		if next == nil {
+			return nil, errors.New("Queue is empty.")
+		}
+		if head == tail {
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		data := next.data
+		if q.head.CompareAndSwap(head, next) {
+			return data, nil
+		}
+	}
+}
+
+//队列是否为空
+func (q *msqueue) Empty() bool {
+	return q.head.Load().next.Load() == nil
+}
+
+//创建无锁无界队列
+func NewMSQueue() *msqueue {
+	q := &msqueue{}
+	dummy := &msnode{}
+	q.head.Store(dummy)
+	q.tail.Store(dummy)
+	return q
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+单生产者单消费者队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//无等待SPSC环形队列
+//Push只能由一个goroutine调用，Pop只能由另一个goroutine调用
+
+type spscqueue struct {
+	buf  []interface{}
+	mask uint64
+	_    pad
+	head atomic.Uint64 //只由消费者写
+	_    pad
+	tail atomic.Uint64 //只由生产者写
+	_    pad
+}
+
+//入队，队列满时返回error
+func (q *spscqueue) Push(data interface{}) error {
+	tail := q.tail.Load()
+	if tail-q.head.Load() == uint64(len(q.buf)) {
+		return errors.New("Queue is full.")
+	}
+	q.buf[tail&q.mask] = data
+	q.tail.Store(tail + 1)
+	return nil
+}
+
+//出队，队列空时返回error
+func (q *spscqueue) Pop() (interface{}, error) {
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return nil, errors.New("Queue is empty.")
+	}
+	data := q.buf[head&q.mask]
+	q.buf[head&q.mask] = nil
+	q.head.Store(head + 1)
+	return data, nil
+}
+
+//队列长度，并发修改时为近似值
+func (q *spscqueue) Len() int {
+	head := q.head.Load()
+	return int(q.tail.Load() - head)
+}
+
+//队列是否为空
+func (q *spscqueue) Empty() bool {
+	return q.Len() == 0
+}
+
+//创建SPSC队列，容量向上取到2的幂
+func NewSPSCQueue(size int) (*spscqueue, error) {
+	if size <= 0 {
+		return nil, errors.New("Can't create queue with zero size.")
+	}
+	n := ceilPow2(size)
+	return &spscqueue{
+		buf:  make([]interface{}, n),
+		mask: n - 1,
+	}, nil
+}
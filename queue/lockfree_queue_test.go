@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+//被测队列，入队由push按Push的签名分派
+type testQueue interface {
+	Pop() (interface{}, error)
+}
+
+//data入队，队列满时返回false
+func push(q testQueue, data interface{}) bool {
+	switch q := q.(type) {
+	case interface{ Push(data interface{}) error }:
+		return q.Push(data) == nil
+	case interface{ Push(data interface{}) }:
+		q.Push(data)
+	}
+	return true
+}
+
+//生产者编号和序号
+type testItem struct {
+	producer, seq int
+}
+
+//producers个生产者各入队n个元素，consumers个消费者并发出队
+//检查每个元素恰好出队一次，且同一消费者看到的同一生产者的元素保持入队顺序
+func stress(t *testing.T, q testQueue, producers, consumers, n int) {
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				for !push(q, testItem{p, i}) {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+	seen := make([][]bool, producers)
+	for p := range seen {
+		seen[p] = make([]bool, n)
+	}
+	var lock sync.Mutex
+	remaining := producers * n
+	errs := make(chan error, consumers)
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			last := make([]int, producers)
+			for p := range last {
+				last[p] = -1
+			}
+			for {
+				lock.Lock()
+				done := remaining == 0
+				lock.Unlock()
+				if done {
+					return
+				}
+				data, err := q.Pop()
+				if err != nil {
+					runtime.Gosched()
+					continue
+				}
+				item := data.(testItem)
+				if item.seq <= last[item.producer] {
+					errs <- fmt.Errorf("producer %d: got %d after %d", item.producer, item.seq, last[item.producer])
+					return
+				}
+				last[item.producer] = item.seq
+				lock.Lock()
+				if seen[item.producer][item.seq] {
+					lock.Unlock()
+					errs <- fmt.Errorf("item %v popped twice", item)
+					return
+				}
+				seen[item.producer][item.seq] = true
+				remaining--
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	cwg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if remaining != 0 {
+		t.Fatalf("%d items lost", remaining)
+	}
+}
+
+func TestMPMCQueueConcurrent(t *testing.T) {
+	q, _ := NewMPMCQueue(64)
+	stress(t, q, 4, 4, 5000)
+}
+
+func TestMSQueueConcurrent(t *testing.T) {
+	stress(t, NewMSQueue(), 4, 4, 5000)
+}
+
+func TestSPSCQueueConcurrent(t *testing.T) {
+	q, _ := NewSPSCQueue(64)
+	stress(t, q, 1, 1, 20000)
+}
+
+func TestLockFreeQueueEmptyFull(t *testing.T) {
+	m, _ := NewMPMCQueue(3)
+	s, _ := NewSPSCQueue(3)
+	for i := 0; i < 4; i++ {
+		if m.Push(i) != nil || s.Push(i) != nil {
+			t.Fatalf("push %d failed before capacity 4", i)
+		}
+	}
+	if m.Push(4) == nil || s.Push(4) == nil {
+		t.Fatal("push succeeded on full queue")
+	}
+	for i := 0; i < 4; i++ {
+		a, _ := m.Pop()
+		b, _ := s.Pop()
+		if a != i || b != i {
+			t.Fatalf("pop %d: got %v, %v", i, a, b)
+		}
+	}
+	if _, err := m.Pop(); err == nil {
+		t.Fatal("pop succeeded on empty MPMC queue")
+	}
+	if _, err := s.Pop(); err == nil {
+		t.Fatal("pop succeeded on empty SPSC queue")
+	}
+	if _, err := NewMSQueue().Pop(); err == nil {
+		t.Fatal("pop succeeded on empty MS queue")
+	}
+}
+
+//producers个生产者共入队b.N个元素，一个消费者全部取出
+func benchmarkQueue(b *testing.B, q testQueue, producers int) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		n := b.N / producers
+		if p < b.N%producers {
+			n++
+		}
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				for !push(q, i) {
+					runtime.Gosched()
+				}
+			}
+		}(n)
+	}
+	for i := 0; i < b.N; {
+		if _, err := q.Pop(); err == nil {
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+	wg.Wait()
+}
+
+func BenchmarkQueues(b *testing.B) {
+	for _, producers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("MPMC/producers=%d", producers), func(b *testing.B) {
+			q, _ := NewMPMCQueue(1024)
+			benchmarkQueue(b, q, producers)
+		})
+		b.Run(fmt.Sprintf("MS/producers=%d", producers), func(b *testing.B) {
+			benchmarkQueue(b, NewMSQueue(), producers)
+		})
+		b.Run(fmt.Sprintf("SQueue/producers=%d", producers), func(b *testing.B) {
+			benchmarkQueue(b, NewSQueue(), producers)
+		})
+	}
+	//SPSC只允许一个生产者
+	b.Run("SPSC/producers=1", func(b *testing.B) {
+		q, _ := NewSPSCQueue(1024)
+		benchmarkQueue(b, q, 1)
+	})
+}
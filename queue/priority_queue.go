@@ -0,0 +1,269 @@
+package queue
+
+import (
+	"errors"
+)
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+带下标的堆+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//二叉小顶堆，元素记录自己在堆中的下标，因此可以按句柄删除
+//PriorityQueue和vis_queue的超时堆共用
+
+type heap_entry[E any] interface {
+	before(other E) bool
+	setIndex(i int) //-1表示已不在堆中
+}
+
+type indexed_heap[E heap_entry[E]] struct {
+	items []E
+}
+
+func (h *indexed_heap[E]) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].setIndex(i)
+	h.items[j].setIndex(j)
+}
+
+func (h *indexed_heap[E]) up(child int) {
+	for child > 0 {
+		parent := (child - 1) / 2
+		if !h.items[child].before(h.items[parent]) {
+			break
+		}
+		h.swap(child, parent)
+		child = parent
+	}
+}
+
+func (h *indexed_heap[E]) down(parent int) {
+	n := len(h.items)
+	for {
+		left, right := 2*parent+1, 2*parent+2
+		if left >= n {
+			break
+		}
+		index := left
+		if right < n && h.items[right].before(h.items[left]) {
+			index = right
+		}
+		if !h.items[index].before(h.items[parent]) {
+			break
+		}
+		h.swap(parent, index)
+		parent = index
+	}
+}
+
+func (h *indexed_heap[E]) push(e E) {
+	e.setIndex(len(h.items))
+	h.items = append(h.items, e)
+	h.up(len(h.items) - 1)
+}
+
+//删除下标i处的元素
+func (h *indexed_heap[E]) remove(i int) E {
+	last := len(h.items) - 1
+	if i != last {
+		h.swap(i, last)
+	}
+	e := h.items[last]
+	var zero E
+	h.items[last] = zero
+	h.items = h.items[:last]
+	if i < last {
+		h.down(i)
+		h.up(i)
+	}
+	e.setIndex(-1)
+	return e
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+优先队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//二叉堆实现，priority越小越先出队
+//每个元素带入队序号，优先级相同时按入队顺序出队
+
+//优先队列中的元素，Insert返回，可用于Remove
+type pq_item[T any] struct {
+	data     T
+	priority int
+	seq      uint64
+	index    int //在堆中的下标，-1表示已出队或已删除
+}
+
+func (a *pq_item[T]) before(b *pq_item[T]) bool {
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	}
+	return a.seq < b.seq
+}
+
+func (a *pq_item[T]) setIndex(i int) {
+	a.index = i
+}
+
+type PriorityQueue[T any] struct {
+	heap indexed_heap[*pq_item[T]]
+	seq  uint64
+}
+
+//创建优先队列
+func NewPriorityQueue[T any]() *PriorityQueue[T] {
+	return &PriorityQueue[T]{}
+}
+
+//入队
+func (q *PriorityQueue[T]) Push(data T, priority int) {
+	q.Insert(data, priority)
+}
+
+//入队，返回的句柄可用于Remove
+func (q *PriorityQueue[T]) Insert(data T, priority int) *pq_item[T] {
+	item := &pq_item[T]{data: data, priority: priority, seq: q.seq}
+	q.seq++
+	q.heap.push(item)
+	return item
+}
+
+//删除尚未出队的元素，成功时返回true
+func (q *PriorityQueue[T]) Remove(item *pq_item[T]) bool {
+	if item == nil || item.index < 0 || item.index >= len(q.heap.items) || q.heap.items[item.index] != item {
+		return false
+	}
+	q.heap.remove(item.index)
+	return true
+}
+
+//出队
+func (q *PriorityQueue[T]) Pop() (T, error) {
+	data, _, err := q.PopWithPriority()
+	return data, err
+}
+
+//出队，同时返回优先级
+func (q *PriorityQueue[T]) PopWithPriority() (T, int, error) {
+	if len(q.heap.items) == 0 {
+		var zero T
+		return zero, 0, errors.New("Pop with empty queue.")
+	}
+	top := q.heap.remove(0)
+	return top.data, top.priority, nil
+}
+
+//取队头
+func (q *PriorityQueue[T]) Head() (T, error) {
+	if len(q.heap.items) == 0 {
+		var zero T
+		return zero, errors.New("Empty queue error.")
+	}
+	return q.heap.items[0].data, nil
+}
+
+//队列长度
+func (q *PriorityQueue[T]) Len() int {
+	return len(q.heap.items)
+}
+
+//队列是否为空
+func (q *PriorityQueue[T]) Empty() bool {
+	return len(q.heap.items) == 0
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+多级队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//每级一个FIFO队列，0级优先级最高
+//出队按平滑加权轮询在非空的级别间选择，权重大的级别出队更多，
+//但只要权重大于0，低优先级的级别也不会饿死
+
+type mlqueue struct {
+	levels  []queue
+	weights []int
+	current []int //平滑加权轮询的当前权重
+	count   int
+}
+
+//创建多级队列，级数为len(weights)，weights[i]为第i级的权重
+func NewMultiLevelQueue(weights ...int) (*mlqueue, error) {
+	if len(weights) == 0 {
+		return nil, errors.New("Can't create queue with zero levels.")
+	}
+	for _, w := range weights {
+		if w <= 0 {
+			return nil, errors.New("Weight must be positive.")
+		}
+	}
+	q := &mlqueue{
+		levels:  make([]queue, len(weights)),
+		weights: make([]int, len(weights)),
+		current: make([]int, len(weights)),
+	}
+	copy(q.weights, weights)
+	return q, nil
+}
+
+//入队到第level级
+func (q *mlqueue) Push(data interface{}, level int) error {
+	if level < 0 || level >= len(q.levels) {
+		return errors.New("Level out of range.")
+	}
+	q.levels[level].Push(data)
+	q.count++
+	return nil
+}
+
+//选出下一个出队的级别，调用者须保证非空
+func (q *mlqueue) next() int {
+	total, best := 0, -1
+	for i := range q.levels {
+		if q.levels[i].Empty() {
+			continue
+		}
+		q.current[i] += q.weights[i]
+		total += q.weights[i]
+		if best < 0 || q.current[i] > q.current[best] {
+			best = i
+		}
+	}
+	q.current[best] -= total
+	return best
+}
+
+//出队
+func (q *mlqueue) Pop() (interface{}, error) {
+	data, _, err := q.PopWithLevel()
+	return data, err
+}
+
+//出队，同时返回所在级别
+func (q *mlqueue) PopWithLevel() (interface{}, int, error) {
+	if q.count == 0 {
+		return nil, 0, errors.New("Pop with empty queue.")
+	}
+	level := q.next()
+	data, _ := q.levels[level].Pop()
+	q.count--
+	return data, level, nil
+}
+
+//级数
+func (q *mlqueue) Levels() int {
+	return len(q.levels)
+}
+
+//第level级的元素个数
+func (q *mlqueue) LevelLen(level int) int {
+	if level < 0 || level >= len(q.levels) {
+		return 0
+	}
+	return q.levels[level].Len()
+}
+
+//队列长度
+func (q *mlqueue) Len() int {
+	return q.count
+}
+
+//队列是否为空
+func (q *mlqueue) Empty() bool {
+	return q.count == 0
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+//随机Insert、Remove、Pop，与按(优先级, 入队顺序)排序的参照比较
+func TestPriorityQueueRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	q := NewPriorityQueue[int]()
+	type ref struct {
+		item           *pq_item[int]
+		priority, data int
+	}
+	live := []ref{}
+	removed := []*pq_item[int]{}
+	for step := 0; step < 5000; step++ {
+		switch op := r.Intn(10); {
+		case op < 5:
+			p := r.Intn(20)
+			live = append(live, ref{q.Insert(step, p), p, step})
+		case op < 7:
+			if len(live) == 0 {
+				continue
+			}
+			k := r.Intn(len(live))
+			if !q.Remove(live[k].item) {
+				t.Fatalf("step %d: Remove of queued item failed", step)
+			}
+			removed = append(removed, live[k].item)
+			live = append(live[:k], live[k+1:]...)
+		default:
+			sort.SliceStable(live, func(i, j int) bool { return live[i].priority < live[j].priority })
+			data, p, err := q.PopWithPriority()
+			if len(live) == 0 {
+				if err == nil {
+					t.Fatalf("step %d: Pop on empty queue returned %d", step, data)
+				}
+				continue
+			}
+			if err != nil || data != live[0].data || p != live[0].priority {
+				t.Fatalf("step %d: Pop = %d, %d, %v, want %d, %d", step, data, p, err, live[0].data, live[0].priority)
+			}
+			removed = append(removed, live[0].item)
+			live = live[1:]
+		}
+		if len(removed) > 0 && q.Remove(removed[r.Intn(len(removed))]) {
+			t.Fatalf("step %d: Remove of removed item succeeded", step)
+		}
+		if q.Len() != len(live) {
+			t.Fatalf("step %d: Len = %d, want %d", step, q.Len(), len(live))
+		}
+	}
+	if NewPriorityQueue[int]().Remove(q.Insert(0, 0)) {
+		t.Fatal("Remove through another queue succeeded")
+	}
+}
@@ -9,13 +9,14 @@ import (
 
 /*-+-+-+-+-+-+-+-+-+-+-+-+-+-+队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
 
+//环形缓冲区实现，出队的槽位会被释放，长期使用内存占用稳定
 type queue struct {
-	queue []interface{}
+	ring
 }
 
 //入队
 func (q *queue) Push(data interface{}) {
-	q.queue = append(q.queue, data)
+	q.pushBack(data)
 }
 
 //出队
@@ -23,9 +24,30 @@ func (q *queue) Pop() (interface{}, error) {
 	if q.Empty() {
 		return nil, errors.New("Pop with empty queue.")
 	}
-	data := q.queue[0]
-	q.queue = q.queue[1:]
-	return data, nil
+	return q.popFront(), nil
+}
+
+//批量入队
+func (q *queue) PushAll(items ...interface{}) {
+	q.pushAll(items)
+}
+
+//批量出队，最多取n个
+func (q *queue) PopN(n int) []interface{} {
+	if n > q.count {
+		n = q.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := make([]interface{}, n)
+	q.drainTo(res)
+	return res
+}
+
+//出队到dst，最多取len(dst)个，返回取出的个数
+func (q *queue) DrainTo(dst []interface{}) int {
+	return q.drainTo(dst)
 }
 
 //取队头
@@ -33,21 +55,17 @@ func (q *queue) Head() (interface{}, error) {
 	if q.Empty() {
 		return nil, errors.New("Empty queue error.")
 	}
-	data := q.queue[0]
-	return data, nil
+	return q.at(0), nil
 }
 
 //队列长度
 func (q *queue) Len() int {
-	return len(q.queue)
+	return q.count
 }
 
 //队列是否为空
 func (q *queue) Empty() bool {
-	if len(q.queue) <= 0 {
-		return true
-	}
-	return false
+	return q.count == 0
 }
 
 func (q *queue) String() string {
@@ -55,8 +73,8 @@ func (q *queue) String() string {
 		return "Empty Queue."
 	}
 	str := ""
-	for _, v := range q.queue {
-		str += fmt.Sprintf("%v ", v)
+	for i := 0; i < q.count; i++ {
+		str += fmt.Sprintf("%v ", q.at(i))
 	}
 	buf := bytes.NewBufferString(strings.Repeat("_", len(str)))
 	buf.WriteString("\n")
@@ -68,25 +86,24 @@ func (q *queue) String() string {
 
 //创建队列
 func NewQueue() *queue {
-	return &queue{
-		queue: []interface{}{},
-	}
+	return &queue{}
 }
 
 /*-+-+-+-+-+-+-+-+-+-+-+-+-+-+双端队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
 
+//环形缓冲区实现，两端操作均摊O(1)
 type dqueue struct {
-	queue []interface{}
+	ring
 }
 
 //在队头插入
 func (q *dqueue) PushFront(data interface{}) {
-	q.queue = append([]interface{}{data}, q.queue...)
+	q.pushFront(data)
 }
 
 //在队尾插入
 func (q *dqueue) PushBack(data interface{}) {
-	q.queue = append(q.queue, data)
+	q.pushBack(data)
 }
 
 //从队头出队
@@ -94,9 +111,7 @@ func (q *dqueue) PopFront() (interface{}, error) {
 	if q.Empty() {
 		return nil, errors.New("Pop with empty queue")
 	}
-	data := q.queue[0]
-	q.queue = q.queue[1:]
-	return data, nil
+	return q.popFront(), nil
 }
 
 //从队尾出队
@@ -104,10 +119,7 @@ func (q *dqueue) PopBack() (interface{}, error) {
 	if q.Empty() {
 		return nil, errors.New("Pop with empty queue")
 	}
-	index := len(q.queue) - 1
-	data := q.queue[index]
-	q.queue = q.queue[0:index]
-	return data, nil
+	return q.popBack(), nil
 }
 
 //取队头
@@ -115,8 +127,7 @@ func (q *dqueue) Head() (interface{}, error) {
 	if q.Empty() {
 		return nil, errors.New("Empty dqueue error")
 	}
-	data := q.queue[0]
-	return data, nil
+	return q.at(0), nil
 }
 
 //取队尾
@@ -124,22 +135,25 @@ func (q *dqueue) Tail() (interface{}, error) {
 	if q.Empty() {
 		return nil, errors.New("Empty dqueue error")
 	}
-	index := len(q.queue) - 1
-	data := q.queue[index]
-	return data, nil
+	return q.at(q.count - 1), nil
+}
+
+//取从队头数第i个元素
+func (q *dqueue) At(i int) (interface{}, error) {
+	if i < 0 || i >= q.count {
+		return nil, errors.New("Index out of range.")
+	}
+	return q.at(i), nil
 }
 
 //队列长度
 func (q *dqueue) Len() int {
-	return len(q.queue)
+	return q.count
 }
 
 //队列是否为空
 func (q *dqueue) Empty() bool {
-	if len(q.queue) == 0 {
-		return true
-	}
-	return false
+	return q.count == 0
 }
 
 func (q *dqueue) String() string {
@@ -147,8 +161,8 @@ func (q *dqueue) String() string {
 		return "Empty Queue."
 	}
 	str := ""
-	for _, v := range q.queue {
-		str += fmt.Sprintf("%v ", v)
+	for i := 0; i < q.count; i++ {
+		str += fmt.Sprintf("%v ", q.at(i))
 	}
 	buf := bytes.NewBufferString(strings.Repeat("_", len(str)))
 	buf.WriteString("\n")
@@ -160,9 +174,7 @@ func (q *dqueue) String() string {
 
 //创建双端队列
 func NewDQueue() *dqueue {
-	return &dqueue{
-		queue: []interface{}{},
-	}
+	return &dqueue{}
 }
 
 /*-+-+-+-+-+-+-+-+-+-+-+-+-+-+循环队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
@@ -172,17 +184,71 @@ func NewDQueue() *dqueue {
 // head[0]          tail[3]
 
 type cqueue struct {
-	queue []interface{}
-	head  int
-	tail  int
-	count int
-	size  int
+	queue      []interface{}
+	head       int
+	tail       int
+	count      int
+	size       int
+	overwrite  bool                   //满时覆盖最早的元素
+	evict      func(data interface{}) //元素被覆盖时的回调
+	autoGrow   bool                   //满时容量翻倍
+	autoShrink bool                   //元素不足容量的1/4时容量减半
+	minSize    int                    //自动缩容的下限，即创建时的容量
+}
+
+//设置满时覆盖模式
+//开启后队列满时入队会淘汰最早的元素，evict不为nil时以被淘汰的元素调用
+func (q *cqueue) SetOverwrite(overwrite bool, evict ...func(data interface{})) {
+	q.overwrite = overwrite
+	q.evict = nil
+	if len(evict) > 0 {
+		q.evict = evict[0]
+	}
+}
+
+//设置自动伸缩，grow为true时满了自动扩容（优先于覆盖模式），
+//shrink为true时元素不足容量的1/4自动缩容，但不会小于创建时的容量
+func (q *cqueue) SetAutoResize(grow, shrink bool) {
+	q.autoGrow, q.autoShrink = grow, shrink
+}
+
+//调整容量为newSize，调用者须保证newSize不小于元素个数
+func (q *cqueue) resize(newSize int) {
+	nq := make([]interface{}, newSize, newSize)
+	copyOut(nq[:q.count], q.queue, q.head)
+	q.queue = nq
+	q.head, q.tail, q.size = 0, q.count%newSize, newSize
+}
+
+//自动缩容
+func (q *cqueue) autoResize() {
+	for q.autoShrink && q.size/2 >= q.minSize && q.count <= q.size/4 {
+		q.resize(q.size / 2)
+	}
+}
+
+//淘汰队头的n个元素
+func (q *cqueue) drop(n int) {
+	for ; n > 0; n-- {
+		data := q.queue[q.head]
+		q.queue[q.head] = nil
+		q.head = (q.head + 1) % q.size
+		q.count--
+		if q.evict != nil {
+			q.evict(data)
+		}
+	}
 }
 
 //入队
 func (q *cqueue) Push(data interface{}) error {
-	if q.count == q.size {
-		return errors.New("Queue is full.")
+	if q.count == q.size && q.autoGrow {
+		q.resize(2 * q.size)
+	} else if q.count == q.size {
+		if !q.overwrite {
+			return errors.New("Queue is full.")
+		}
+		q.drop(1)
 	}
 
 	q.queue[q.tail] = data
@@ -200,9 +266,80 @@ func (q *cqueue) Pop() (interface{}, error) {
 	data := q.queue[q.head]
 	q.head = (q.head + 1) % q.size
 	q.count--
+	q.autoResize()
 	return data, nil
 }
 
+//批量入队，剩余空间不足时不入队并返回error
+//覆盖模式下淘汰最早的元素，items比容量还多时只保留最后的部分
+func (q *cqueue) PushAll(items ...interface{}) error {
+	if q.autoGrow {
+		q.Grow(len(items))
+	}
+	if q.count+len(items) > q.size {
+		if !q.overwrite {
+			return errors.New("Queue is full.")
+		}
+		if len(items) > q.size {
+			q.drop(q.count)
+			skip := items[:len(items)-q.size]
+			items = items[len(items)-q.size:]
+			for _, data := range skip {
+				if q.evict != nil {
+					q.evict(data)
+				}
+			}
+		} else {
+			q.drop(q.count + len(items) - q.size)
+		}
+	}
+	copyIn(q.queue, q.tail, items)
+	q.tail = (q.tail + len(items)) % q.size
+	q.count += len(items)
+	return nil
+}
+
+//批量出队，最多取n个
+func (q *cqueue) PopN(n int) []interface{} {
+	if n > q.count {
+		n = q.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := make([]interface{}, n)
+	q.DrainTo(res)
+	return res
+}
+
+//出队到dst，最多取len(dst)个，返回取出的个数
+func (q *cqueue) DrainTo(dst []interface{}) int {
+	n := len(dst)
+	if n > q.count {
+		n = q.count
+	}
+	copyOut(dst[:n], q.queue, q.head)
+	q.head = (q.head + n) % q.size
+	q.count -= n
+	q.autoResize()
+	return n
+}
+
+//最近入队的n个元素，按入队顺序排列
+func (q *cqueue) Last(n int) []interface{} {
+	if n > q.count {
+		n = q.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := make([]interface{}, n)
+	for i := range res {
+		res[i] = q.queue[(q.head+q.count-n+i)%q.size]
+	}
+	return res
+}
+
 //取队头
 func (q *cqueue) Head() (interface{}, error) {
 	if q.count == 0 {
@@ -228,18 +365,43 @@ func (q *cqueue) Full() bool {
 	return q.count == q.size
 }
 
+//队列容量
+func (q *cqueue) Cap() int {
+	return q.size
+}
+
+//保证至少还能入队n个元素，需要扩容时容量至少翻倍
+func (q *cqueue) Grow(n int) {
+	if n <= q.size-q.count {
+		return
+	}
+	newSize := 2 * q.size
+	if newSize < q.count+n {
+		newSize = q.count + n
+	}
+	q.resize(newSize)
+}
+
+//容量缩小到刚好容纳现有元素
+func (q *cqueue) Shrink() {
+	if q.count == 0 {
+		q.resize(1)
+	} else if q.count < q.size {
+		q.resize(q.count)
+	}
+}
+
 //重新设置循环队列大小
+//newSize小于元素个数时，覆盖模式下淘汰最早的元素，否则返回error
 func (q *cqueue) Resize(newSize int) (int, error) {
-	if newSize <= q.count {
+	if newSize <= 0 || (newSize < q.count && !q.overwrite) {
 		return q.size, errors.New("New size is too small.")
 	}
 	old := q.size
-	nq := make([]interface{}, newSize, newSize)
-	for i := 0; i < q.count; i++ {
-		nq[i] = q.queue[(q.head+i)%q.size]
+	if newSize < q.count {
+		q.drop(q.count - newSize)
 	}
-	q.queue = nq
-	q.head, q.tail, q.size = 0, q.count, newSize
+	q.resize(newSize)
 	return old, nil
 }
 
@@ -266,10 +428,11 @@ func NewCQueue(size int) (*cqueue, error) {
 		return nil, errors.New("Can't create loop queue with zero size.")
 	}
 	return &cqueue{
-		queue: make([]interface{}, size, size),
-		head:  0,
-		tail:  0,
-		count: 0,
-		size:  size,
+		queue:   make([]interface{}, size, size),
+		head:    0,
+		tail:    0,
+		count:   0,
+		size:    size,
+		minSize: size,
 	}, nil
 }
@@ -0,0 +1,245 @@
+package queue
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+//cqueue和scqueue共有的操作
+type circular interface {
+	Push(data interface{}) error
+	Pop() (interface{}, error)
+	PushAll(items ...interface{}) error
+	PopN(n int) []interface{}
+	DrainTo(dst []interface{}) int
+	Last(n int) []interface{}
+	SetOverwrite(overwrite bool, evict ...func(data interface{}))
+	SetAutoResize(grow, shrink bool)
+	Grow(n int)
+	Shrink()
+	Resize(newSize int) (int, error)
+	Len() int
+	Cap() int
+}
+
+//循环队列的参照模型，用切片保存元素，size为应有的容量
+type model struct {
+	items                []interface{}
+	size, minSize        int
+	overwrite, evicting  bool
+	autoGrow, autoShrink bool
+	evicted              []interface{}
+}
+
+func (m *model) evict(items []interface{}) {
+	if m.evicting {
+		m.evicted = append(m.evicted, items...)
+	}
+}
+
+func (m *model) shrink() {
+	for m.autoShrink && m.size/2 >= m.minSize && len(m.items) <= m.size/4 {
+		m.size /= 2
+	}
+}
+
+func (m *model) grow(n int) {
+	if n <= m.size-len(m.items) {
+		return
+	}
+	m.size *= 2
+	if m.size < len(m.items)+n {
+		m.size = len(m.items) + n
+	}
+}
+
+func (m *model) push(data interface{}) bool {
+	if len(m.items) == m.size && m.autoGrow {
+		m.size *= 2
+	} else if len(m.items) == m.size {
+		if !m.overwrite {
+			return false
+		}
+		m.evict(m.items[:1])
+		m.items = m.items[1:]
+	}
+	m.items = append(m.items, data)
+	return true
+}
+
+func (m *model) pushAll(items []interface{}) bool {
+	if m.autoGrow {
+		m.grow(len(items))
+	}
+	if len(m.items)+len(items) > m.size {
+		if !m.overwrite {
+			return false
+		}
+		if len(items) > m.size {
+			m.evict(m.items)
+			m.evict(items[:len(items)-m.size])
+			m.items = nil
+			items = items[len(items)-m.size:]
+		} else {
+			n := len(m.items) + len(items) - m.size
+			m.evict(m.items[:n])
+			m.items = m.items[n:]
+		}
+	}
+	m.items = append(m.items, items...)
+	return true
+}
+
+func (m *model) pop(n int) []interface{} {
+	if n > len(m.items) {
+		n = len(m.items)
+	}
+	if n < 0 {
+		n = 0
+	}
+	res := append([]interface{}{}, m.items[:n]...)
+	m.items = m.items[n:]
+	m.shrink()
+	return res
+}
+
+func (m *model) last(n int) []interface{} {
+	if n > len(m.items) {
+		n = len(m.items)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return append([]interface{}{}, m.items[len(m.items)-n:]...)
+}
+
+//随机执行各种操作，每步之后比较元素、容量和被淘汰的元素
+func testCircularModel(t *testing.T, create func(size int) circular) {
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		size := 1 + r.Intn(8)
+		q := create(size)
+		m := &model{size: size, minSize: size}
+		var evicted []interface{}
+		next := 0
+		values := func(n int) []interface{} {
+			res := make([]interface{}, n)
+			for i := range res {
+				res[i] = next
+				next++
+			}
+			return res
+		}
+		for step := 0; step < 400; step++ {
+			var op string
+			switch k := r.Intn(20); {
+			case k < 5:
+				op = "Push"
+				data := values(1)[0]
+				if err := q.Push(data); (err == nil) != m.push(data) {
+					t.Fatalf("round %d step %d: Push = %v", round, step, err)
+				}
+			case k < 8:
+				op = "Pop"
+				data, err := q.Pop()
+				var want []interface{}
+				if len(m.items) > 0 { //空队列出队失败时不会缩容
+					want = m.pop(1)
+				}
+				if (err == nil) != (len(want) == 1) || (err == nil && data != want[0]) {
+					t.Fatalf("round %d step %d: Pop = %v, %v, want %v", round, step, data, err, want)
+				}
+			case k < 10:
+				op = "PushAll"
+				items := values(r.Intn(2 * size))
+				if err := q.PushAll(items...); (err == nil) != m.pushAll(items) {
+					t.Fatalf("round %d step %d: PushAll(%d) = %v", round, step, len(items), err)
+				}
+			case k < 12:
+				op = "PopN"
+				n := r.Intn(size+2) - 1
+				if got, want := q.PopN(n), m.pop(n); !reflect.DeepEqual(got, want) {
+					t.Fatalf("round %d step %d: PopN(%d) = %v, want %v", round, step, n, got, want)
+				}
+			case k < 13:
+				op = "DrainTo"
+				dst := make([]interface{}, r.Intn(size+1))
+				n := q.DrainTo(dst)
+				if want := m.pop(len(dst)); !reflect.DeepEqual(dst[:n], want) {
+					t.Fatalf("round %d step %d: DrainTo = %v, want %v", round, step, dst[:n], want)
+				}
+			case k < 14:
+				op = "Last"
+				n := r.Intn(size+2) - 1
+				if got, want := q.Last(n), m.last(n); !reflect.DeepEqual(got, want) {
+					t.Fatalf("round %d step %d: Last(%d) = %v, want %v", round, step, n, got, want)
+				}
+			case k < 15:
+				op = "SetOverwrite"
+				m.overwrite, m.evicting = r.Intn(2) == 0, r.Intn(2) == 0
+				if m.evicting {
+					q.SetOverwrite(m.overwrite, func(data interface{}) { evicted = append(evicted, data) })
+				} else {
+					q.SetOverwrite(m.overwrite)
+				}
+			case k < 16:
+				op = "SetAutoResize"
+				m.autoGrow, m.autoShrink = r.Intn(2) == 0, r.Intn(2) == 0
+				q.SetAutoResize(m.autoGrow, m.autoShrink)
+			case k < 17:
+				op = "Grow"
+				n := r.Intn(2 * size)
+				q.Grow(n)
+				m.grow(n)
+			case k < 18:
+				op = "Shrink"
+				q.Shrink()
+				if len(m.items) == 0 {
+					m.size = 1
+				} else {
+					m.size = len(m.items)
+				}
+			default:
+				op = "Resize"
+				n := r.Intn(2*size+1) - 1
+				old, err := q.Resize(n)
+				ok := n > 0 && (n >= len(m.items) || m.overwrite)
+				if (err == nil) != ok || old != m.size {
+					t.Fatalf("round %d step %d: Resize(%d) = %d, %v, want %d", round, step, n, old, err, m.size)
+				}
+				if ok {
+					if n < len(m.items) {
+						m.evict(m.items[:len(m.items)-n])
+						m.items = m.items[len(m.items)-n:]
+					}
+					m.size = n
+				}
+			}
+			if got := q.Last(q.Len()); !reflect.DeepEqual(got, m.last(len(m.items))) {
+				t.Fatalf("round %d step %d after %s: items = %v, want %v", round, step, op, got, m.items)
+			}
+			if q.Cap() != m.size {
+				t.Fatalf("round %d step %d after %s: Cap = %d, want %d", round, step, op, q.Cap(), m.size)
+			}
+			if fmt.Sprint(evicted) != fmt.Sprint(m.evicted) {
+				t.Fatalf("round %d step %d after %s: evicted %v, want %v", round, step, op, evicted, m.evicted)
+			}
+		}
+	}
+}
+
+func TestCQueueModel(t *testing.T) {
+	testCircularModel(t, func(size int) circular {
+		q, _ := NewCQueue(size)
+		return q
+	})
+}
+
+func TestSCQueueModel(t *testing.T) {
+	testCircularModel(t, func(size int) circular {
+		q, _ := NewSCQueue(size)
+		return q
+	})
+}
@@ -0,0 +1,152 @@
+package queue
+
+/*
+ * 可伸缩环形缓冲区
+ * 满时容量翻倍，元素不足容量的1/4时容量减半
+ * 两端的插入和删除均摊O(1)，出队的槽位会被清空以便GC回收
+ */
+
+const minRingSize = 8
+
+type ring struct {
+	buf   []interface{}
+	head  int
+	count int
+}
+
+//调整容量为size，元素移到缓冲区开头
+func (r *ring) resize(size int) {
+	nb := make([]interface{}, size)
+	if r.count > 0 {
+		end := r.head + r.count
+		if end > len(r.buf) {
+			end = len(r.buf)
+		}
+		n := copy(nb, r.buf[r.head:end])
+		copy(nb[n:], r.buf[:r.count-n])
+	}
+	r.buf, r.head = nb, 0
+}
+
+func (r *ring) grow() {
+	if r.count == len(r.buf) {
+		size := 2 * len(r.buf)
+		if size < minRingSize {
+			size = minRingSize
+		}
+		r.resize(size)
+	}
+}
+
+func (r *ring) shrink() {
+	if len(r.buf) > minRingSize && r.count <= len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+}
+
+//第i个元素在缓冲区中的下标
+func (r *ring) index(i int) int {
+	return (r.head + i) % len(r.buf)
+}
+
+func (r *ring) pushBack(data interface{}) {
+	r.grow()
+	r.buf[r.index(r.count)] = data
+	r.count++
+}
+
+func (r *ring) pushFront(data interface{}) {
+	r.grow()
+	r.head = (r.head - 1 + len(r.buf)) % len(r.buf)
+	r.buf[r.head] = data
+	r.count++
+}
+
+//调用者须保证非空
+func (r *ring) popFront() interface{} {
+	data := r.buf[r.head]
+	r.buf[r.head] = nil
+	r.head = r.index(1)
+	r.count--
+	r.shrink()
+	return data
+}
+
+//调用者须保证非空
+func (r *ring) popBack() interface{} {
+	i := r.index(r.count - 1)
+	data := r.buf[i]
+	r.buf[i] = nil
+	r.count--
+	r.shrink()
+	return data
+}
+
+//调用者须保证0 <= i < count
+func (r *ring) at(i int) interface{} {
+	return r.buf[r.index(i)]
+}
+
+//按顺序返回所有元素的副本
+func (r *ring) items() []interface{} {
+	res := make([]interface{}, r.count)
+	for i := range res {
+		res[i] = r.at(i)
+	}
+	return res
+}
+
+//批量追加到队尾
+func (r *ring) pushAll(items []interface{}) {
+	if len(items) == 0 {
+		return
+	}
+	if r.count+len(items) > len(r.buf) {
+		size := len(r.buf)
+		if size < minRingSize {
+			size = minRingSize
+		}
+		for size < r.count+len(items) {
+			size *= 2
+		}
+		r.resize(size)
+	}
+	copyIn(r.buf, r.index(r.count), items)
+	r.count += len(items)
+}
+
+//从队头取出最多len(dst)个元素到dst，返回取出的个数
+func (r *ring) drainTo(dst []interface{}) int {
+	n := len(dst)
+	if n > r.count {
+		n = r.count
+	}
+	if n == 0 {
+		return 0
+	}
+	copyOut(dst[:n], r.buf, r.head)
+	r.head = r.index(n)
+	r.count -= n
+	r.shrink()
+	return n
+}
+
+//将items复制到环形缓冲区buf中从start开始的位置，最多两次copy
+func copyIn(buf []interface{}, start int, items []interface{}) {
+	n := copy(buf[start:], items)
+	copy(buf, items[n:])
+}
+
+//从环形缓冲区buf的start处复制len(dst)个元素到dst并清空这些槽位，最多两次copy
+func copyOut(dst []interface{}, buf []interface{}, start int) {
+	n := copy(dst, buf[start:])
+	copy(dst[n:], buf)
+	release(buf[start : start+n])
+	release(buf[:len(dst)-n])
+}
+
+func release(s []interface{}) {
+	for i := range s {
+		s[i] = nil
+	}
+}
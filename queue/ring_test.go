@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"runtime"
+	"testing"
+)
+
+//GC后正在使用的堆内存
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+//成批入队再全部出队，队列清空后缓冲区应缩回最小容量，内存占用不随轮数增长
+func TestQueueMemoryStable(t *testing.T) {
+	q := NewQueue()
+	s := NewSQueue()
+	payload := make([]byte, 64)
+	round := func() {
+		for i := 0; i < 10000; i++ {
+			q.Push(payload)
+			s.Push(payload)
+		}
+		for !q.Empty() {
+			q.Pop()
+			s.Pop()
+		}
+	}
+	round()
+	before := heapInUse()
+	for i := 0; i < 200; i++ {
+		round()
+	}
+	after := heapInUse()
+	if len(q.buf) != minRingSize || len(s.buf) != minRingSize {
+		t.Fatalf("buffers not shrunk: %d, %d", len(q.buf), len(s.buf))
+	}
+	if after > before && after-before > 1<<20 {
+		t.Fatalf("heap in use grew from %d to %d bytes", before, after)
+	}
+}
+
+//出队的槽位被清空，元素不会因残留在缓冲区中而无法回收
+func TestQueueReleasesSlots(t *testing.T) {
+	q := NewQueue()
+	for i := 0; i < 100; i++ {
+		q.Push(make([]byte, 1<<20))
+	}
+	for i := 0; i < 99; i++ {
+		q.Pop()
+	}
+	if inuse := heapInUse(); inuse > 16<<20 {
+		t.Fatalf("heap in use %d bytes after popping 99 of 100 MiB-sized items", inuse)
+	}
+	runtime.KeepAlive(q)
+}
+
+//长时间入队出队前后的堆内存，例如100M次：
+//go test -run NONE -bench PushPop -benchtime 100000000x
+func benchmarkPushPop(b *testing.B, push func(interface{}), pop func() (interface{}, error)) {
+	const burst = 1024
+	before := heapInUse()
+	b.ResetTimer()
+	for i := 0; i < b.N; i += burst {
+		n := burst
+		if b.N-i < n {
+			n = b.N - i
+		}
+		for j := 0; j < n; j++ {
+			push(j)
+		}
+		for j := 0; j < n; j++ {
+			pop()
+		}
+	}
+	b.StopTimer()
+	after := heapInUse()
+	b.ReportMetric(float64(before), "heap-before-B")
+	b.ReportMetric(float64(after), "heap-after-B")
+}
+
+func BenchmarkQueuePushPop(b *testing.B) {
+	q := NewQueue()
+	benchmarkPushPop(b, q.Push, q.Pop)
+}
+
+func BenchmarkSQueuePushPop(b *testing.B) {
+	q := NewSQueue()
+	benchmarkPushPop(b, q.Push, q.Pop)
+}
@@ -0,0 +1,148 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+可见性超时队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//Pop取出的元素在可见性超时内对其他消费者隐藏，Ack后才真正删除
+//超时未Ack的元素重新回到队尾，投递次数加一
+//投递次数达到上限仍未Ack的元素移入死信队列
+
+//Pop返回的消息
+type Message struct {
+	ID         uint64
+	Data       interface{}
+	Deliveries int //投递次数，第一次Pop时为1
+}
+
+type vis_entry struct {
+	msg      Message
+	deadline time.Time
+	index    int //在timers中的下标
+}
+
+func (a *vis_entry) before(b *vis_entry) bool {
+	return a.deadline.Before(b.deadline)
+}
+
+func (a *vis_entry) setIndex(i int) {
+	a.index = i
+}
+
+type vis_queue struct {
+	ready         *squeue
+	dead          *squeue
+	inflight      map[uint64]*vis_entry
+	timers        indexed_heap[*vis_entry] //已出队未Ack的元素，按超时时刻排序
+	timeout       time.Duration
+	maxDeliveries int
+	next          uint64
+	now           func() time.Time
+	lock          sync.Mutex
+}
+
+//创建可见性超时队列，timeout为可见性超时，
+//maxDeliveries为进入死信队列前的最大投递次数，<=0时不限次数
+//now为当前时间，测试时可注入假时钟，未指定时使用time.Now
+func NewVisibilityQueue(timeout time.Duration, maxDeliveries int, now ...func() time.Time) (*vis_queue, error) {
+	if timeout <= 0 {
+		return nil, errors.New("Timeout must be positive.")
+	}
+	q := &vis_queue{
+		ready:         NewSQueue(),
+		dead:          NewSQueue(),
+		inflight:      map[uint64]*vis_entry{},
+		timeout:       timeout,
+		maxDeliveries: maxDeliveries,
+		now:           time.Now,
+	}
+	if len(now) > 0 && now[0] != nil {
+		q.now = now[0]
+	}
+	return q, nil
+}
+
+//把超时未Ack的元素放回队尾或移入死信队列，调用时须持有锁
+func (q *vis_queue) expire() {
+	now := q.now()
+	for len(q.timers.items) > 0 && !q.timers.items[0].deadline.After(now) {
+		e := q.timers.remove(0)
+		delete(q.inflight, e.msg.ID)
+		if q.maxDeliveries > 0 && e.msg.Deliveries >= q.maxDeliveries {
+			q.dead.Push(e.msg)
+		} else {
+			q.ready.Push(e)
+		}
+	}
+}
+
+//入队，返回元素的id
+func (q *vis_queue) Push(data interface{}) uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	id := q.next
+	q.next++
+	q.ready.Push(&vis_entry{msg: Message{ID: id, Data: data}})
+	return id
+}
+
+//出队，元素在可见性超时内被隐藏，须在超时前Ack
+func (q *vis_queue) Pop() (Message, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.expire()
+	data, err := q.ready.Pop()
+	if err != nil {
+		return Message{}, err
+	}
+	e := data.(*vis_entry)
+	e.msg.Deliveries++
+	e.deadline = q.now().Add(q.timeout)
+	q.inflight[e.msg.ID] = e
+	q.timers.push(e)
+	return e.msg, nil
+}
+
+//确认元素已处理完毕并删除，已超时的元素不能再Ack
+func (q *vis_queue) Ack(id uint64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.expire()
+	e, ok := q.inflight[id]
+	if !ok {
+		return errors.New("Unknown id.")
+	}
+	delete(q.inflight, id)
+	q.timers.remove(e.index)
+	return nil
+}
+
+//死信队列，其中的元素为Message
+func (q *vis_queue) DeadLetters() *squeue {
+	return q.dead
+}
+
+//可出队的元素个数
+func (q *vis_queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.expire()
+	return q.ready.Len()
+}
+
+//已出队未Ack且未超时的元素个数
+func (q *vis_queue) Inflight() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.expire()
+	return len(q.inflight)
+}
+
+//队列是否为空（不含已出队未Ack的元素）
+func (q *vis_queue) Empty() bool {
+	return q.Len() == 0
+}
@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+//只有Advance时才前进的时钟
+type fakeNow struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *fakeNow) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeNow) Advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	c.lock.Unlock()
+}
+
+func newVis(t *testing.T, maxDeliveries int) (*vis_queue, *fakeNow) {
+	t.Helper()
+	c := &fakeNow{now: time.Unix(0, 0)}
+	q, err := NewVisibilityQueue(time.Second, maxDeliveries, c.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q, c
+}
+
+func popVis(t *testing.T, q *vis_queue) Message {
+	t.Helper()
+	msg, err := q.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+//超时未Ack的元素回到队尾重新投递，投递次数加一
+func TestVisibilityQueueRedelivery(t *testing.T) {
+	q, c := newVis(t, 0)
+	a := q.Push("a")
+	q.Push("b")
+	if msg := popVis(t, q); msg.ID != a || msg.Deliveries != 1 {
+		t.Fatalf("Pop = %+v, want id %d, 1 delivery", msg, a)
+	}
+	if q.Len() != 1 || q.Inflight() != 1 {
+		t.Fatalf("Len = %d, Inflight = %d, want 1, 1", q.Len(), q.Inflight())
+	}
+	c.Advance(999 * time.Millisecond)
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d before timeout, want 1", q.Len())
+	}
+	c.Advance(time.Millisecond)
+	if q.Len() != 2 || q.Inflight() != 0 {
+		t.Fatalf("Len = %d, Inflight = %d after timeout, want 2, 0", q.Len(), q.Inflight())
+	}
+	msg := popVis(t, q)
+	if msg.Data != "b" || msg.Deliveries != 1 {
+		t.Fatalf("Pop = %+v, want b with 1 delivery", msg)
+	}
+	q.Ack(msg.ID)
+	for want := 2; want <= 4; want++ {
+		msg := popVis(t, q)
+		if msg.ID != a || msg.Deliveries != want {
+			t.Fatalf("Pop = %+v, want id %d with %d deliveries", msg, a, want)
+		}
+		c.Advance(time.Second)
+	}
+}
+
+//投递次数达到上限仍未Ack的元素移入死信队列
+func TestVisibilityQueueDeadLetter(t *testing.T) {
+	q, c := newVis(t, 2)
+	id := q.Push("x")
+	for i := 0; i < 2; i++ {
+		popVis(t, q)
+		c.Advance(time.Second)
+	}
+	if !q.Empty() || q.Inflight() != 0 {
+		t.Fatalf("Len = %d, Inflight = %d, want 0, 0", q.Len(), q.Inflight())
+	}
+	data, err := q.DeadLetters().Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := data.(Message); msg.ID != id || msg.Deliveries != 2 {
+		t.Fatalf("dead letter = %+v, want id %d with 2 deliveries", msg, id)
+	}
+}
+
+//超时前Ack的元素不再投递，超时后的Ack失败
+func TestVisibilityQueueAck(t *testing.T) {
+	q, c := newVis(t, 0)
+	a := q.Push("a")
+	b := q.Push("b")
+	popVis(t, q)
+	c.Advance(500 * time.Millisecond)
+	popVis(t, q)
+	if err := q.Ack(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack(b); err == nil {
+		t.Fatal("second Ack succeeded")
+	}
+	c.Advance(500 * time.Millisecond)
+	if err := q.Ack(a); err == nil {
+		t.Fatal("Ack after timeout succeeded")
+	}
+	if msg := popVis(t, q); msg.ID != a || msg.Deliveries != 2 {
+		t.Fatalf("Pop = %+v, want id %d with 2 deliveries", msg, a)
+	}
+	if err := q.Ack(a); err != nil {
+		t.Fatal(err)
+	}
+	c.Advance(time.Hour)
+	if !q.Empty() || q.Inflight() != 0 {
+		t.Fatalf("Len = %d, Inflight = %d, want 0, 0", q.Len(), q.Inflight())
+	}
+}
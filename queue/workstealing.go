@@ -0,0 +1,249 @@
+package queue
+
+import (
+	"errors"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+工作窃取双端队列+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//Chase-Lev双端队列
+//与sdqueue不同，底部只能由拥有者一个goroutine操作（PushBottom/PopBottom），不加锁；
+//其他goroutine通过Steal从顶部窃取，仅在争抢同一个元素时使用CAS
+
+type ws_item struct {
+	data interface{}
+}
+
+//容量为2的幂的环形数组，扩容时整体替换
+type ws_ring struct {
+	buf  []atomic.Pointer[ws_item]
+	mask int64
+}
+
+func newWSRing(size int64) *ws_ring {
+	return &ws_ring{
+		buf:  make([]atomic.Pointer[ws_item], size),
+		mask: size - 1,
+	}
+}
+
+func (r *ws_ring) get(i int64) *ws_item {
+	return r.buf[i&r.mask].Load()
+}
+
+func (r *ws_ring) put(i int64, item *ws_item) {
+	r.buf[i&r.mask].Store(item)
+}
+
+//复制[top, bottom)到容量翻倍的新数组
+func (r *ws_ring) grow(top, bottom int64) *ws_ring {
+	nr := newWSRing(2 * int64(len(r.buf)))
+	for i := top; i < bottom; i++ {
+		nr.put(i, r.get(i))
+	}
+	return nr
+}
+
+type ws_deque struct {
+	top    atomic.Int64
+	_      pad
+	bottom atomic.Int64
+	_      pad
+	array  atomic.Pointer[ws_ring]
+}
+
+//创建工作窃取队列
+func NewWSDeque() *ws_deque {
+	q := &ws_deque{}
+	q.array.Store(newWSRing(minRingSize))
+	return q
+}
+
+//从底部入队，只能由拥有者调用
+func (q *ws_deque) PushBottom(data interface{}) {
+	b := q.bottom.Load()
+	t := q.top.Load()
+	a := q.array.Load()
+	if b-t > a.mask {
+		a = a.grow(t, b)
+		q.array.Store(a)
+	}
+	a.put(b, &ws_item{data})
+	q.bottom.Store(b + 1)
+}
+
+//从底部出队，只能由拥有者调用
+func (q *ws_deque) PopBottom() (interface{}, error) {
+	b := q.bottom.Load() - 1
+	a := q.array.Load()
+	q.bottom.Store(b)
+	t := q.top.Load()
+	if t > b {
+		q.bottom.Store(b + 1)
+		return nil, errors.New("Queue is empty.")
+	}
+	item := a.get(b)
+	if t == b { //最后一个元素，与窃取者竞争
+		won := q.top.CompareAndSwap(t, t+1)
+		q.bottom.Store(b + 1)
+		if !won {
+			return nil, errors.New("Queue is empty.")
+		}
+	}
+	return item.data, nil
+}
+
+//从顶部窃取，可由任意goroutine调用
+func (q *ws_deque) Steal() (interface{}, error) {
+	for {
+		t := q.top.Load()
+		b := q.bottom.Load()
+		if t >= b {
+			return nil, errors.New("Queue is empty.")
+		}
+		item := q.array.Load().get(t)
+		if q.top.CompareAndSwap(t, t+1) {
+			return item.data, nil
+		}
+	}
+}
+
+//队列长度，并发修改时为近似值
+func (q *ws_deque) Len() int {
+	b := q.bottom.Load()
+	t := q.top.Load()
+	if b < t {
+		return 0
+	}
+	return int(b - t)
+}
+
+//队列是否为空
+func (q *ws_deque) Empty() bool {
+	return q.Len() == 0
+}
+
+/*-+-+-+-+-+-+-+-+-+-+-+-+-+-+工作窃取线程池+-+-+-+-+-+-+-+-+-+-+-+-+-+-*/
+
+//演示ws_deque的简单线程池
+//Submit提交的任务先进入全局队列，工作者每次从全局队列取一批放到自己的deque，
+//自己的deque和全局队列都空时随机窃取其他工作者的任务
+
+const stealBatch = 16
+
+type work_pool struct {
+	deques  []*ws_deque
+	global  *msqueue
+	notify  chan struct{} //有新任务时唤醒空闲工作者
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+	closed  atomic.Bool
+	done    chan struct{}
+}
+
+//创建有workers个工作者的线程池，workers<=0时取CPU核数
+func NewWorkPool(workers int) *work_pool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	p := &work_pool{
+		deques: make([]*ws_deque, workers),
+		global: NewMSQueue(),
+		notify: make(chan struct{}, workers),
+		done:   make(chan struct{}),
+	}
+	for i := range p.deques {
+		p.deques[i] = NewWSDeque()
+	}
+	p.workers.Add(workers)
+	for i := range p.deques {
+		go p.work(i)
+	}
+	return p
+}
+
+//提交任务，Close后返回ErrClosed
+func (p *work_pool) Submit(task func()) error {
+	if p.closed.Load() {
+		return ErrClosed
+	}
+	p.pending.Add(1)
+	p.global.Push(task)
+	p.wake()
+	return nil
+}
+
+//唤醒一个空闲工作者，都在忙时不阻塞
+func (p *work_pool) wake() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+//等待已提交的任务全部完成后停止所有工作者
+//不能与Submit并发调用
+func (p *work_pool) Close() {
+	if p.closed.Swap(true) {
+		return
+	}
+	p.pending.Wait()
+	close(p.done)
+	p.workers.Wait()
+}
+
+func (p *work_pool) work(id int) {
+	defer p.workers.Done()
+	own := p.deques[id]
+	for {
+		task, ok := p.next(id, own)
+		if ok {
+			task.(func())()
+			p.pending.Done()
+			continue
+		}
+		select {
+		case <-p.notify:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+//依次从自己的deque、全局队列、其他工作者取任务
+//从全局队列搬来多个任务时唤醒一个空闲工作者，让它从这里窃取
+func (p *work_pool) next(id int, own *ws_deque) (interface{}, bool) {
+	if task, err := own.PopBottom(); err == nil {
+		return task, true
+	}
+	moved := 0
+	for ; moved < stealBatch; moved++ {
+		task, err := p.global.Pop()
+		if err != nil {
+			break
+		}
+		own.PushBottom(task)
+	}
+	if moved > 1 {
+		p.wake()
+	}
+	if task, err := own.PopBottom(); err == nil {
+		return task, true
+	}
+	n := len(p.deques)
+	start := rand.Intn(n)
+	for i := 0; i < n; i++ {
+		victim := (start + i) % n
+		if victim == id {
+			continue
+		}
+		if task, err := p.deques[victim].Steal(); err == nil {
+			return task, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+//拥有者成批PushBottom并穿插PopBottom，多个窃取者并发Steal
+//检查每个元素恰好取出一次，成批入队使环形数组多次扩容
+func TestWSDequeExactlyOnce(t *testing.T) {
+	const n, stealers = 100000, 4
+	q := NewWSDeque()
+	seen := make([]int32, n)
+	var taken atomic.Int64
+	record := func(data interface{}) {
+		atomic.AddInt32(&seen[data.(int)], 1)
+		taken.Add(1)
+	}
+	var wg sync.WaitGroup
+	for s := 0; s < stealers; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for taken.Load() < n {
+				if data, err := q.Steal(); err == nil {
+					record(data)
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; {
+		burst := 1 + i%1000
+		for j := 0; j < burst && i < n; j++ {
+			q.PushBottom(i)
+			i++
+		}
+		for j := 0; j < burst/2; j++ {
+			if data, err := q.PopBottom(); err == nil {
+				record(data)
+			}
+		}
+	}
+	for {
+		data, err := q.PopBottom()
+		if err != nil {
+			break
+		}
+		record(data)
+	}
+	wg.Wait()
+	for i, c := range seen {
+		if c != 1 {
+			t.Fatalf("item %d taken %d times", i, c)
+		}
+	}
+	if size := q.array.Load().mask + 1; size <= minRingSize {
+		t.Fatalf("ring never grew, size %d", size)
+	}
+	if !q.Empty() {
+		t.Fatalf("Len = %d after draining", q.Len())
+	}
+}
+
+//多个goroutine并发Submit，Close等所有任务完成，之后Submit返回ErrClosed
+func TestWorkPool(t *testing.T) {
+	const submitters, n = 4, 5000
+	p := NewWorkPool(4)
+	var count atomic.Int64
+	var wg sync.WaitGroup
+	for s := 0; s < submitters; s++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				if err := p.Submit(func() { count.Add(1) }); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	within(t, "Close", p.Close)
+	if got := count.Load(); got != submitters*n {
+		t.Fatalf("%d tasks ran, want %d", got, submitters*n)
+	}
+	if err := p.Submit(func() {}); err != ErrClosed {
+		t.Fatalf("Submit after Close = %v, want ErrClosed", err)
+	}
+	within(t, "second Close", p.Close)
+}
+
+//一个工作者从全局队列搬走一批任务后，空闲工作者被唤醒并窃取，任务得以并行执行
+func TestWorkPoolSteal(t *testing.T) {
+	const workers = 4
+	p := NewWorkPool(workers)
+	var running atomic.Int32
+	release := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		p.Submit(func() {
+			running.Add(1)
+			<-release
+		})
+	}
+	within(t, "all workers", func() {
+		for running.Load() < workers {
+			runtime.Gosched()
+		}
+	})
+	close(release)
+	within(t, "Close", p.Close)
+}